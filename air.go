@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -96,6 +98,25 @@ var TLSKeyFile = ""
 // It is called "https_enforced" in the configuration file.
 var HTTPSEnforced = false
 
+// QueryParamArrayCSVEnabled indicates whether a single query param value is
+// split by the commas in the `Request#QueryParamArray()`.
+//
+// It is called "query_param_array_csv_enabled" in the configuration file.
+var QueryParamArrayCSVEnabled = false
+
+// XMLContentTypeLenient indicates whether the `Request#DecodeXML()` decodes the
+// request bodies whose content types are not XML.
+//
+// It is called "xml_content_type_lenient" in the configuration file.
+var XMLContentTypeLenient = false
+
+// CookieJSONEncoding is the encoding of the cookie values decoded by the
+// `Request#CookieJSON()`. It must be the "url" (URL-encoded) or the "base64"
+// (URL-safe base64-encoded, with or without the padding).
+//
+// It is called "cookie_json_encoding" in the configuration file.
+var CookieJSONEncoding = "url"
+
 // ErrorHandler is the centralized error handler for the server.
 var ErrorHandler = func(err error, req *Request, res *Response) {
 	e := &Error{500, "Internal Server Error"}
@@ -242,6 +263,15 @@ func init() {
 		if v, ok := Config["https_enforced"].(bool); ok {
 			HTTPSEnforced = v
 		}
+		if v, ok := Config["query_param_array_csv_enabled"].(bool); ok {
+			QueryParamArrayCSVEnabled = v
+		}
+		if v, ok := Config["xml_content_type_lenient"].(bool); ok {
+			XMLContentTypeLenient = v
+		}
+		if v, ok := Config["cookie_json_encoding"].(string); ok {
+			CookieJSONEncoding = v
+		}
 		if v, ok := Config["auto_push_enabled"].(bool); ok {
 			AutoPushEnabled = v
 		}
@@ -301,6 +331,13 @@ func Shutdown(timeout time.Duration) error {
 	return theServer.shutdown(timeout)
 }
 
+// ServeHTTP serves the r by writing the response into the rw through the
+// `Pregases`, the `Gases` and the router. It makes it possible to serve the
+// requests without starting the server.
+func ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	theServer.ServeHTTP(rw, r)
+}
+
 // INFO logs the v at the INFO level.
 func INFO(v ...interface{}) {
 	theLogger.log("INFO", v...)
@@ -410,6 +447,21 @@ func FILE(path, file string, gases ...Gas) {
 	HEAD(path, h, gases...)
 }
 
+// BindParam converts the p into the type of the v and sets the v, which must be
+// settable, to it in the same way as the `Request#Bind()` binds the params. An
+// empty p stands for the zero value. It returns an error when the p cannot be
+// converted or the kind of the v is not a string, bool, int, uint or float.
+func BindParam(v reflect.Value, p string) error {
+	return theBinder.bindParam(v, p)
+}
+
+// Minify minifies the b based on the mimeType regardless of the
+// `MinifierEnabled` by using the same minifier as the `Response`. The b is
+// returned as it is when the mimeType is not supported.
+func Minify(mimeType string, b []byte) ([]byte, error) {
+	return theMinifier.forceMinify(mimeType, b)
+}
+
 // Handler defines a function to serve requests.
 type Handler func(*Request, *Response) error
 
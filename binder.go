@@ -34,7 +34,9 @@ func (b *binder) bind(v interface{}, r *Request) error {
 	case "application/xml":
 		err = xml.NewDecoder(r.Body).Decode(v)
 	case "application/x-www-form-urlencoded", "multipart/form-data":
-		err = b.bindParams(v, r.Params)
+		if err = r.ParseForm(); err == nil {
+			err = b.bindParams(v, r.Params)
+		}
 	default:
 		return &Error{415, "Unsupported Media Type"}
 	}
@@ -75,56 +77,65 @@ func (b *binder) bindParams(v interface{}, params map[string]string) error {
 			continue
 		}
 
-		switch tf.Type.Kind() {
-		case reflect.Int,
-			reflect.Int8,
-			reflect.Int16,
-			reflect.Int32,
-			reflect.Int64:
-			if p == "" {
-				p = "0"
-			}
-			v, err := strconv.ParseInt(p, 10, 64)
-			if err != nil {
-				return err
-			}
-			vf.SetInt(v)
-		case reflect.Uint,
-			reflect.Uint8,
-			reflect.Uint16,
-			reflect.Uint32,
-			reflect.Uint64:
-			if p == "" {
-				p = "0"
-			}
-			v, err := strconv.ParseUint(p, 10, 64)
-			if err != nil {
-				return err
-			}
-			vf.SetUint(v)
-		case reflect.Bool:
-			if p == "" {
-				p = "false"
-			}
-			v, err := strconv.ParseBool(p)
-			if err != nil {
-				return err
-			}
-			vf.SetBool(v)
-		case reflect.Float32, reflect.Float64:
-			if p == "" {
-				p = "0.0"
-			}
-			v, err := strconv.ParseFloat(p, 64)
-			if err != nil {
-				return err
-			}
-			vf.SetFloat(v)
-		case reflect.String:
-			vf.SetString(p)
-		default:
-			return errors.New("unknown type")
+		if err := b.bindParam(vf, p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bindParam converts the p into the type of the v and sets the v to it.
+func (b *binder) bindParam(v reflect.Value, p string) error {
+	switch v.Kind() {
+	case reflect.Int,
+		reflect.Int8,
+		reflect.Int16,
+		reflect.Int32,
+		reflect.Int64:
+		if p == "" {
+			p = "0"
+		}
+		i, err := strconv.ParseInt(p, 10, v.Type().Bits())
+		if err != nil {
+			return err
 		}
+		v.SetInt(i)
+	case reflect.Uint,
+		reflect.Uint8,
+		reflect.Uint16,
+		reflect.Uint32,
+		reflect.Uint64:
+		if p == "" {
+			p = "0"
+		}
+		u, err := strconv.ParseUint(p, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Bool:
+		if p == "" {
+			p = "false"
+		}
+		b, err := strconv.ParseBool(p)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		if p == "" {
+			p = "0.0"
+		}
+		f, err := strconv.ParseFloat(p, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		v.SetString(p)
+	default:
+		return errors.New("unknown type")
 	}
 
 	return nil
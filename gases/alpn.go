@@ -0,0 +1,21 @@
+package gases
+
+import "github.com/sheng/air"
+
+// RequireHTTP2 returns a `air.Gas` that rejects the requests not negotiated over
+// the HTTP/2 with the 505. The requests skipped by any of the optional skippers
+// (e.g. the health checks) pass through.
+func RequireHTTP2(skippers ...Skipper) air.Gas {
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			if req.Proto != "HTTP/2" && !skipped(skippers, req) {
+				return &air.Error{
+					Code:    505,
+					Message: "HTTP Version Not Supported",
+				}
+			}
+
+			return next(req, res)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireHTTP2(t *testing.T) {
+	h := func(req *air.Request, res *air.Response) error {
+		return res.String("foobar")
+	}
+
+	air.GET("/alpn", h, RequireHTTP2())
+	air.GET("/alpn/health", h, RequireHTTP2(func(req *air.Request) bool {
+		return req.URL.Path == "/alpn/health"
+	}))
+
+	req := httptest.NewRequest("GET", "/alpn", nil)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 505, rec.Code)
+	assert.Equal(t, "HTTP Version Not Supported", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/alpn", nil)
+	req.Proto = "HTTP/2.0"
+	req.ProtoMajor = 2
+	req.ProtoMinor = 0
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "foobar", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/alpn/health", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+}
@@ -0,0 +1,70 @@
+package gases
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/sheng/air"
+)
+
+// VersionConfig is the configuration of the `APIVersion`.
+type VersionConfig struct {
+	// Header is the name of the header that carries the version. It
+	// defaults to "Accept-Version".
+	Header string
+
+	// Supported is the supported versions.
+	Supported []string
+
+	// Default is the version used when the request does not specify one.
+	Default string
+}
+
+// APIVersion returns a `air.Gas` that resolves the API version of each request
+// based on the config and stores it in the `air.Request.Values` with the key
+// "api_version". The version is read from the `VersionConfig.Header` or, if
+// the header is absent, the "version" param of the media types in the "Accept"
+// header (e.g. "application/vnd.api+json; version=2"). The requests with
+// unsupported versions are rejected with the 406.
+func APIVersion(config VersionConfig) air.Gas {
+	if config.Header == "" {
+		config.Header = "Accept-Version"
+	}
+
+	config.Header = http.CanonicalHeaderKey(config.Header)
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			v := strings.TrimSpace(req.Headers[config.Header])
+			if v == "" {
+				for _, ar := range strings.Split(
+					req.Headers["Accept"],
+					",",
+				) {
+					_, ps, err := mime.ParseMediaType(ar)
+					if err == nil && ps["version"] != "" {
+						v = ps["version"]
+						break
+					}
+				}
+			}
+
+			if v == "" {
+				v = config.Default
+			}
+
+			for _, sv := range config.Supported {
+				if v == sv {
+					req.Values["api_version"] = v
+					return next(req, res)
+				}
+			}
+
+			return &air.Error{
+				Code:    406,
+				Message: "unsupported API version: " + v,
+			}
+		}
+	}
+}
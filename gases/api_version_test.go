@@ -0,0 +1,53 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIVersion(t *testing.T) {
+	air.GET(
+		"/api_version",
+		func(req *air.Request, res *air.Response) error {
+			return res.String(req.GetString("api_version"))
+		},
+		APIVersion(VersionConfig{
+			Header:    "x-api-version",
+			Supported: []string{"1", "2"},
+			Default:   "1",
+		}),
+	)
+
+	req := httptest.NewRequest("GET", "/api_version", nil)
+	req.Header.Set("X-API-Version", "2")
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "2", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/api_version", nil)
+	req.Header.Set(
+		"Accept",
+		"text/html, application/vnd.api+json; version=2",
+	)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "2", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/api_version", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "1", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/api_version", nil)
+	req.Header.Set("X-API-Version", "3")
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 406, rec.Code)
+	assert.Equal(t, "unsupported API version: 3", rec.Body.String())
+}
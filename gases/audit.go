@@ -0,0 +1,101 @@
+package gases
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sheng/air"
+)
+
+// AuditConfig is the configuration of the `Audit`.
+type AuditConfig struct {
+	// Sink is the destination of the emitted `AuditRecord`s.
+	Sink AuditSink
+
+	// ActorKey is the key of the actor of a request in the
+	// `air.Request.Values`. It is treated as the "actor" when it is empty.
+	ActorKey string
+}
+
+// AuditRecord is an audit record of a request.
+type AuditRecord struct {
+	Time     time.Time
+	Actor    string
+	Method   string
+	Path     string
+	Status   int
+	PrevHash string
+	Hash     string
+}
+
+// Digest returns the hex-encoded SHA-256 hash of the ar chained with the
+// `AuditRecord.PrevHash`.
+func (ar *AuditRecord) Digest() string {
+	h := sha256.New()
+	h.Write([]byte(ar.PrevHash + "\n" +
+		strconv.FormatInt(ar.Time.UnixNano(), 10) + "\n" +
+		ar.Actor + "\n" +
+		ar.Method + "\n" +
+		ar.Path + "\n" +
+		strconv.Itoa(ar.Status)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AuditSink is the destination of the `AuditRecord`s emitted by the `Audit`.
+type AuditSink interface {
+	// Write writes the ar. It is called for the records one by one in the
+	// order they are chained.
+	Write(ar *AuditRecord) error
+}
+
+// Audit returns a `air.Gas` that emits an `AuditRecord` to the
+// `AuditConfig.Sink` for each request after the subsequent handlers based on
+// the config. The actor of a record is read from the `air.Request.Values` with
+// the `AuditConfig.ActorKey`, and its hash chains the hash of the previous
+// record, so that any retroactive tampering of the records can be detected.
+// The errors of the sink are logged.
+func Audit(config AuditConfig) air.Gas {
+	if config.Sink == nil {
+		panic("gases: audit sink can't be nil")
+	}
+
+	if config.ActorKey == "" {
+		config.ActorKey = "actor"
+	}
+
+	mutex := &sync.Mutex{}
+	prevHash := ""
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			ar := &AuditRecord{
+				Time:   time.Now(),
+				Method: req.Method,
+				Path:   req.URL.Path,
+			}
+
+			err := next(req, res)
+
+			ar.Actor = req.GetString(config.ActorKey)
+
+			ar.Status = res.StatusCode
+			if err != nil {
+				ar.Status = httpError(err).Code
+			}
+
+			mutex.Lock()
+			ar.PrevHash = prevHash
+			ar.Hash = ar.Digest()
+			prevHash = ar.Hash
+			if serr := config.Sink.Write(ar); serr != nil {
+				air.ERROR(serr)
+			}
+			mutex.Unlock()
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+type auditSink []*AuditRecord
+
+func (as *auditSink) Write(ar *AuditRecord) error {
+	*as = append(*as, ar)
+	return nil
+}
+
+func TestAudit(t *testing.T) {
+	sink := &auditSink{}
+	g := Audit(AuditConfig{
+		Sink:     sink,
+		ActorKey: "user",
+	})
+
+	air.GET(
+		"/audit/:actor",
+		func(req *air.Request, res *air.Response) error {
+			req.Values["user"] = req.Params["actor"]
+			return res.String("foobar")
+		},
+		g,
+	)
+
+	air.POST(
+		"/audit/:actor",
+		func(req *air.Request, res *air.Response) error {
+			return &air.Error{
+				Code:    403,
+				Message: "Forbidden",
+			}
+		},
+		g,
+	)
+
+	for _, r := range [][2]string{
+		{"GET", "/audit/foo"},
+		{"POST", "/audit/bar"},
+		{"GET", "/audit/bar"},
+	} {
+		req := httptest.NewRequest(r[0], r[1], nil)
+		air.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Len(t, *sink, 3)
+
+	ars := *sink
+	assert.Equal(t, "foo", ars[0].Actor)
+	assert.Equal(t, "GET", ars[0].Method)
+	assert.Equal(t, "/audit/foo", ars[0].Path)
+	assert.Equal(t, 200, ars[0].Status)
+	assert.Equal(t, "", ars[1].Actor)
+	assert.Equal(t, "POST", ars[1].Method)
+	assert.Equal(t, 403, ars[1].Status)
+	assert.Equal(t, "bar", ars[2].Actor)
+
+	assert.Empty(t, ars[0].PrevHash)
+	for i, ar := range ars {
+		assert.Len(t, ar.Hash, 64)
+		assert.Equal(t, ar.Digest(), ar.Hash)
+		if i > 0 {
+			assert.Equal(t, ars[i-1].Hash, ar.PrevHash)
+		}
+	}
+
+	ars[1].Status = 200
+	assert.NotEqual(t, ars[1].Digest(), ars[1].Hash)
+
+	assert.Panics(t, func() {
+		Audit(AuditConfig{})
+	})
+}
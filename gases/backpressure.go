@@ -0,0 +1,56 @@
+package gases
+
+import (
+	"math/rand"
+	"strconv"
+
+	"github.com/sheng/air"
+)
+
+// BackpressureConfig is the configuration of the `Backpressure`.
+type BackpressureConfig struct {
+	// Load returns the current load.
+	Load func() float64
+
+	// Threshold is the load above which the requests start to be shed.
+	Threshold float64
+
+	// RetryAfter is the number of seconds in the "Retry-After" header of
+	// the shed requests. It is treated as 1 when it is less than 1.
+	RetryAfter int
+}
+
+// Backpressure returns a `air.Gas` that samples the `BackpressureConfig.Load`
+// before the subsequent handlers and, when it exceeds the
+// `BackpressureConfig.Threshold`, sheds a fraction of the requests
+// proportional to the overload with the 503 and a "Retry-After" header. All
+// the requests are shed once the load reaches twice the threshold.
+func Backpressure(config BackpressureConfig) air.Gas {
+	if config.RetryAfter < 1 {
+		config.RetryAfter = 1
+	}
+
+	retryAfter := strconv.Itoa(config.RetryAfter)
+	threshold := config.Threshold
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			if l := config.Load(); l > threshold {
+				p := 1.0
+				if threshold > 0 {
+					p = (l - threshold) / threshold
+				}
+
+				if rand.Float64() < p {
+					res.Headers["Retry-After"] = retryAfter
+					return &air.Error{
+						Code:    503,
+						Message: "Service Unavailable",
+					}
+				}
+			}
+
+			return next(req, res)
+		}
+	}
+}
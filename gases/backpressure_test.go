@@ -0,0 +1,63 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackpressure(t *testing.T) {
+	load := 0.0
+
+	air.GET(
+		"/backpressure",
+		func(req *air.Request, res *air.Response) error {
+			return res.String("foobar")
+		},
+		Backpressure(BackpressureConfig{
+			Load: func() float64 {
+				return load
+			},
+			Threshold:  1,
+			RetryAfter: 5,
+		}),
+	)
+
+	shed := func() int {
+		n := 0
+		for i := 0; i < 1000; i++ {
+			req := httptest.NewRequest("GET", "/backpressure", nil)
+			rec := httptest.NewRecorder()
+			air.ServeHTTP(rec, req)
+			if rec.Code == 503 {
+				ra := rec.Header().Get("Retry-After")
+				assert.Equal(t, "5", ra)
+				n++
+			} else {
+				assert.Equal(t, 200, rec.Code)
+			}
+		}
+		return n
+	}
+
+	load = 0.5
+	assert.Zero(t, shed())
+
+	load = 1
+	assert.Zero(t, shed())
+
+	load = 1.2
+	low := shed()
+
+	load = 1.6
+	high := shed()
+
+	load = 2
+	all := shed()
+
+	assert.NotZero(t, low)
+	assert.True(t, low < high)
+	assert.Equal(t, 1000, all)
+}
@@ -0,0 +1,195 @@
+package gases
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/sheng/air"
+)
+
+// BatchConfig is the configuration of the `Batch`.
+type BatchConfig struct {
+	// Path is the path that the batches are served at.
+	Path string
+
+	// MaxRequests is the maximum number of the sub-requests of a batch. It
+	// is treated as 20 when it is less than 1.
+	MaxRequests int
+
+	// Parallelism is the maximum number of the sub-requests of a batch that
+	// are dispatched in parallel. They are dispatched sequentially when it
+	// is less than 2.
+	Parallelism int
+}
+
+// batchRequest is a sub-request of a batch.
+type batchRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// batchResponse is a sub-response of a batch.
+type batchResponse struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body"`
+}
+
+// Batch returns a `air.Gas` that serves the "POST" requests to the
+// `BatchConfig.Path` as batches based on the config. It should be used as a
+// pregas so that the path does not need to be registered in the router.
+//
+// The body of a batch is a JSON array of at most the `BatchConfig.MaxRequests`
+// sub-requests, each of which is an object with the "method", "path",
+// "headers" and "body" members. Each sub-request is dispatched through the
+// whole handler chain in-process, and the response is a JSON array of the
+// sub-responses in the same order, each of which is an object with the
+// "status", "headers" (each with all of its values) and "body" members. A
+// failed sub-request never aborts the others, and the sub-requests to the
+// `BatchConfig.Path` itself are rejected.
+func Batch(config BatchConfig) air.Gas {
+	path := config.Path
+
+	if config.MaxRequests < 1 {
+		config.MaxRequests = 20
+	}
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			if req.Method != "POST" || req.URL.Path != path {
+				return next(req, res)
+			}
+
+			if req.Body == nil {
+				return &air.Error{
+					Code:    400,
+					Message: "request body can't be empty",
+				}
+			}
+
+			dec := json.NewDecoder(req.Body)
+			if t, err := dec.Token(); err != nil {
+				return &air.Error{
+					Code:    400,
+					Message: err.Error(),
+				}
+			} else if t != json.Delim('[') {
+				return &air.Error{
+					Code:    400,
+					Message: "batch must be a JSON array",
+				}
+			}
+
+			brs := []*batchRequest{}
+			for dec.More() {
+				if len(brs) == config.MaxRequests {
+					return &air.Error{
+						Code: 400,
+						Message: "too many " +
+							"sub-requests",
+					}
+				}
+
+				br := &batchRequest{}
+				if err := dec.Decode(br); err != nil {
+					return &air.Error{
+						Code:    400,
+						Message: err.Error(),
+					}
+				}
+
+				brs = append(brs, br)
+			}
+
+			if _, err := dec.Token(); err != nil {
+				return &air.Error{
+					Code:    400,
+					Message: err.Error(),
+				}
+			}
+
+			bress := make([]*batchResponse, len(brs))
+			if config.Parallelism > 1 {
+				wg := &sync.WaitGroup{}
+				sem := make(chan struct{}, config.Parallelism)
+				for i, br := range brs {
+					wg.Add(1)
+					sem <- struct{}{}
+					go func(i int, br *batchRequest) {
+						defer func() {
+							<-sem
+							wg.Done()
+						}()
+						bress[i] = dispatchBatchRequest(
+							req,
+							path,
+							br,
+						)
+					}(i, br)
+				}
+
+				wg.Wait()
+			} else {
+				for i, br := range brs {
+					bress[i] = dispatchBatchRequest(
+						req,
+						path,
+						br,
+					)
+				}
+			}
+
+			return res.JSON(bress)
+		}
+	}
+}
+
+// dispatchBatchRequest dispatches the br of the batch req served at the path.
+func dispatchBatchRequest(
+	req *air.Request,
+	path string,
+	br *batchRequest,
+) *batchResponse {
+	u, err := url.ParseRequestURI(br.Path)
+	if err != nil || !strings.HasPrefix(br.Path, "/") ||
+		u.EscapedPath() == path {
+		return &batchResponse{
+			Status:  400,
+			Headers: map[string][]string{},
+			Body:    "invalid sub-request path",
+		}
+	}
+
+	hr, err := http.NewRequest(
+		br.Method,
+		br.Path,
+		strings.NewReader(br.Body),
+	)
+	if err != nil {
+		return &batchResponse{
+			Status:  400,
+			Headers: map[string][]string{},
+			Body:    err.Error(),
+		}
+	}
+
+	hr.Host = req.URL.Host
+	hr.RemoteAddr = req.RemoteAddr
+	for k, v := range br.Headers {
+		hr.Header.Set(k, v)
+	}
+
+	rr := newResponseRecorder(nil)
+	air.ServeHTTP(rr, hr)
+
+	return &batchResponse{
+		Status:  rr.statusCode,
+		Headers: rr.header,
+		Body:    rr.body.String(),
+	}
+}
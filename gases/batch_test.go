@@ -0,0 +1,128 @@
+package gases
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch(t *testing.T) {
+	air.Pregases = append(
+		air.Pregases,
+		Batch(BatchConfig{
+			Path:        "/batch",
+			MaxRequests: 5,
+		}),
+		Batch(BatchConfig{
+			Path:        "/batch/parallel",
+			MaxRequests: 5,
+			Parallelism: 2,
+		}),
+	)
+	defer func() {
+		air.Pregases = air.Pregases[:len(air.Pregases)-2]
+	}()
+
+	air.GET("/batch/foo", func(req *air.Request, res *air.Response) error {
+		res.Cookies = append(res.Cookies, &air.Cookie{
+			Name:  "foo",
+			Value: "bar",
+		}, &air.Cookie{
+			Name:  "bar",
+			Value: "foo",
+		})
+		return res.String("foo " + req.Headers["X-Foo"])
+	})
+
+	air.POST("/batch/bar", func(req *air.Request, res *air.Response) error {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		return res.String("bar " + string(b))
+	})
+
+	air.GET("/batch/error", func(req *air.Request, res *air.Response) error {
+		return &air.Error{
+			Code:    500,
+			Message: "Internal Server Error",
+		}
+	})
+
+	body := `[` +
+		`{"method":"GET","path":"/batch/foo","headers":{"X-Foo":"foo"}},` +
+		`{"method":"GET","path":"/batch/error"},` +
+		`{"method":"POST","path":"/batch/bar","body":"bar"},` +
+		`{"method":"GET","path":"/batch"},` +
+		`{"method":"POST","path":"/batch?foo=bar","body":"[]"}` +
+		`]`
+
+	for _, path := range []string{"/batch", "/batch/parallel"} {
+		body := strings.Replace(body, `"/batch"`, `"`+path+`"`, 1)
+		body = strings.Replace(body, `"/batch?`, `"`+path+`?`, 1)
+
+		req := httptest.NewRequest(
+			"POST",
+			path,
+			strings.NewReader(body),
+		)
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		assert.Equal(t, 200, rec.Code)
+
+		bress := []*batchResponse{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &bress))
+		assert.Len(t, bress, 5)
+		assert.Equal(t, 200, bress[0].Status)
+		assert.Equal(t, "foo foo", bress[0].Body)
+		assert.Equal(
+			t,
+			[]string{"text/plain; charset=utf-8"},
+			bress[0].Headers["Content-Type"],
+		)
+		assert.Len(t, bress[0].Headers["Set-Cookie"], 2)
+		assert.Equal(t, 500, bress[1].Status)
+		assert.Equal(t, "Internal Server Error", bress[1].Body)
+		assert.Equal(t, 200, bress[2].Status)
+		assert.Equal(t, "bar bar", bress[2].Body)
+		assert.Equal(t, 400, bress[3].Status)
+		assert.Equal(t, 400, bress[4].Status)
+	}
+
+	req := httptest.NewRequest("POST", "/batch", strings.NewReader("{"))
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 400, rec.Code)
+
+	req = httptest.NewRequest("POST", "/batch", strings.NewReader("{}"))
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 400, rec.Code)
+	assert.Equal(t, "batch must be a JSON array", rec.Body.String())
+
+	req = httptest.NewRequest("POST", "/batch", strings.NewReader("[{}"))
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 400, rec.Code)
+
+	req = httptest.NewRequest(
+		"POST",
+		"/batch",
+		io.MultiReader(
+			strings.NewReader("[{},{},{},{},{},{},"),
+			iotest.ErrReader(errors.New("foobar")),
+		),
+	)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 400, rec.Code)
+	assert.Equal(t, "too many sub-requests", rec.Body.String())
+}
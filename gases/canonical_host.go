@@ -0,0 +1,38 @@
+package gases
+
+import (
+	"net"
+	"strings"
+
+	"github.com/sheng/air"
+)
+
+// CanonicalHost returns a `air.Gas` that redirects the requests whose host
+// name differs from the host's with the 301 to the same path on the host. The
+// scheme and the query of the requests are preserved.
+func CanonicalHost(host string) air.Gas {
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			if strings.EqualFold(hostname(req.URL.Host), hostname(host)) {
+				return next(req, res)
+			}
+
+			url := req.URL.Scheme + "://" + host + req.URL.Path
+			if req.URL.Query != "" {
+				url += "?" + req.URL.Query
+			}
+
+			res.StatusCode = 301
+
+			return res.Redirect(url)
+		}
+	}
+}
+
+// hostname returns the host name of the host without the port.
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
@@ -0,0 +1,57 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalHost(t *testing.T) {
+	air.GET(
+		"/canonical_host",
+		func(req *air.Request, res *air.Response) error {
+			return res.String("foobar")
+		},
+		CanonicalHost("example.com"),
+	)
+
+	req := httptest.NewRequest(
+		"GET",
+		"http://www.example.com/canonical_host?foo=bar",
+		nil,
+	)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 301, rec.Code)
+	assert.Equal(
+		t,
+		"http://example.com/canonical_host?foo=bar",
+		rec.Header().Get("Location"),
+	)
+
+	req = httptest.NewRequest(
+		"GET",
+		"https://www.example.com/canonical_host",
+		nil,
+	)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 301, rec.Code)
+	assert.Equal(
+		t,
+		"https://example.com/canonical_host",
+		rec.Header().Get("Location"),
+	)
+
+	req = httptest.NewRequest(
+		"GET",
+		"http://example.com:80/canonical_host?foo=bar",
+		nil,
+	)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "foobar", rec.Body.String())
+}
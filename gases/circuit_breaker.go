@@ -0,0 +1,166 @@
+package gases
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sheng/air"
+)
+
+// CBConfig is the configuration of the `CircuitBreaker`.
+type CBConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens a
+	// closed circuit. It is treated as 1 when it is less than 1.
+	FailureThreshold int
+
+	// CooldownPeriod is the duration an open circuit short-circuits the
+	// requests before it becomes half-open.
+	CooldownPeriod time.Duration
+
+	// SuccessThreshold is the number of consecutive successes that closes a
+	// half-open circuit. It is treated as 1 when it is less than 1.
+	SuccessThreshold int
+
+	// Key returns the key of the circuit that the request belongs to. All the
+	// requests share a single circuit when it is nil.
+	Key func(*air.Request) string
+}
+
+// CircuitBreaker returns a `air.Gas` that tracks the failures of the subsequent
+// handlers per circuit based on the config. A failure is an error returned by
+// the subsequent handlers that stands for a 5xx status code or a response with
+// a 5xx status code.
+//
+// A circuit opens when the `CBConfig.FailureThreshold` is crossed and then
+// short-circuits the requests with the 503 during the
+// `CBConfig.CooldownPeriod`. After that, the circuit becomes half-open and lets
+// one request at a time through as a trial while the others are still
+// short-circuited. A failed trial opens the circuit again, and the
+// `CBConfig.SuccessThreshold` consecutive successful trials close it.
+//
+// A closed circuit without failures is forgotten, so only the circuits that
+// are failing are kept.
+func CircuitBreaker(config CBConfig) air.Gas {
+	if config.FailureThreshold < 1 {
+		config.FailureThreshold = 1
+	}
+
+	if config.SuccessThreshold < 1 {
+		config.SuccessThreshold = 1
+	}
+
+	mutex := &sync.Mutex{}
+	circuits := map[string]*circuit{}
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			key := ""
+			if config.Key != nil {
+				key = config.Key(req)
+			}
+
+			mutex.Lock()
+			c, ok := circuits[key]
+			if !ok {
+				c = &circuit{}
+			}
+
+			cooldown := config.CooldownPeriod
+			if c.state == circuitOpen &&
+				time.Since(c.openedAt) >= cooldown {
+				c.state = circuitHalfOpen
+				c.successes = 0
+			}
+
+			trial := false
+			switch {
+			case c.state == circuitOpen,
+				c.state == circuitHalfOpen && c.trialing:
+				mutex.Unlock()
+				return &air.Error{
+					Code:    503,
+					Message: "Service Unavailable",
+				}
+			case c.state == circuitHalfOpen:
+				c.trialing = true
+				trial = true
+				circuits[key] = c
+			}
+
+			mutex.Unlock()
+
+			failed := true
+			defer func() {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				c, ok := circuits[key]
+				if !ok {
+					c = &circuit{}
+				}
+
+				if trial {
+					c.trialing = false
+				}
+
+				c.record(failed, config)
+
+				if c.state == circuitClosed && c.failures == 0 {
+					delete(circuits, key)
+				} else {
+					circuits[key] = c
+				}
+			}()
+
+			err := next(req, res)
+			if err != nil {
+				failed = httpError(err).Code >= 500
+			} else {
+				failed = res.StatusCode >= 500
+			}
+
+			return err
+		}
+	}
+}
+
+// circuit is a circuit of the `CircuitBreaker`.
+type circuit struct {
+	state     circuitState
+	failures  int
+	successes int
+	openedAt  time.Time
+	trialing  bool
+}
+
+// record records the result of a request to the c based on the config.
+func (c *circuit) record(failed bool, config CBConfig) {
+	if failed {
+		c.successes = 0
+		c.failures++
+		if c.state == circuitHalfOpen ||
+			c.failures >= config.FailureThreshold {
+			c.state = circuitOpen
+			c.openedAt = time.Now()
+			c.failures = 0
+		}
+	} else {
+		c.failures = 0
+		if c.state == circuitHalfOpen {
+			c.successes++
+			if c.successes >= config.SuccessThreshold {
+				c.state = circuitClosed
+			}
+		}
+	}
+}
+
+// circuitState is a state of the `circuit`.
+type circuitState uint8
+
+// circuit states
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
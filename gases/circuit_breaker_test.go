@@ -0,0 +1,101 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	failing := true
+	entered := make(chan struct{})
+	blocks := make(chan chan struct{}, 1)
+
+	air.GET(
+		"/circuit_breaker/:key",
+		func(req *air.Request, res *air.Response) error {
+			select {
+			case block := <-blocks:
+				entered <- struct{}{}
+				<-block
+			default:
+			}
+
+			if req.Params["key"] == "missing" {
+				return &air.Error{
+					Code:    404,
+					Message: "Not Found",
+				}
+			}
+
+			if failing {
+				return &air.Error{
+					Code:    500,
+					Message: "Internal Server Error",
+				}
+			}
+			return res.String("foobar")
+		},
+		CircuitBreaker(CBConfig{
+			FailureThreshold: 2,
+			CooldownPeriod:   50 * time.Millisecond,
+			SuccessThreshold: 2,
+			Key: func(req *air.Request) string {
+				return req.Params["key"]
+			},
+		}),
+	)
+
+	do := func(key string) int {
+		req := httptest.NewRequest("GET", "/circuit_breaker/"+key, nil)
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Closed
+	assert.Equal(t, 500, do("foo"))
+	assert.Equal(t, 500, do("foo"))
+
+	// Open
+	assert.Equal(t, 503, do("foo"))
+	assert.Equal(t, 500, do("bar"))
+
+	time.Sleep(60 * time.Millisecond)
+
+	// Half-open with a failed trial
+	assert.Equal(t, 500, do("foo"))
+	assert.Equal(t, 503, do("foo"))
+
+	time.Sleep(60 * time.Millisecond)
+
+	failing = false
+
+	// Half-open with a single trial at a time
+	block := make(chan struct{})
+	blocks <- block
+	code := make(chan int)
+	go func() {
+		code <- do("foo")
+	}()
+	<-entered
+	assert.Equal(t, 503, do("foo"))
+	close(block)
+	assert.Equal(t, 200, <-code)
+	assert.Equal(t, 200, do("foo"))
+
+	failing = true
+
+	// Closed
+	assert.Equal(t, 500, do("foo"))
+	assert.Equal(t, 500, do("foo"))
+	assert.Equal(t, 503, do("foo"))
+
+	// Client errors are not failures
+	assert.Equal(t, 404, do("missing"))
+	assert.Equal(t, 404, do("missing"))
+	assert.Equal(t, 404, do("missing"))
+}
@@ -0,0 +1,149 @@
+package gases
+
+import (
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sheng/air"
+)
+
+// CostConfig is the configuration of the `CostLimit`.
+type CostConfig struct {
+	// Capacity is the maximum number of tokens in the bucket of a client.
+	// It must be at least 1.
+	Capacity int
+
+	// RefillRate is the number of tokens refilled into the bucket of a
+	// client per second.
+	RefillRate float64
+
+	// Cost returns the number of tokens the request consumes. Each request
+	// consumes 1 token when it is nil. A cost greater than the `Capacity`
+	// is treated as the `Capacity`, and a negative one is treated as 0.
+	Cost func(*air.Request) int
+
+	// Key returns the key of the client that the request belongs to. The
+	// host of the remote address of the request is used when it is nil.
+	Key func(*air.Request) string
+}
+
+// CostLimit returns a `air.Gas` that throttles the requests by their weighted
+// cost from a per-client token bucket based on the config. The over-budget
+// requests are rejected with the 429 and a "Retry-After" header.
+//
+// The buckets that have been idle long enough to be refilled to the
+// `CostConfig.Capacity` are evicted at most once a minute, since they are no
+// different from the new ones.
+func CostLimit(config CostConfig) air.Gas {
+	if config.Capacity < 1 {
+		panic("gases: cost limit capacity must be at least 1")
+	}
+
+	mutex := &sync.Mutex{}
+	buckets := map[string]*costBucket{}
+	sweptAt := time.Now()
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			cost := 1
+			if config.Cost != nil {
+				cost = config.Cost(req)
+			}
+
+			if cost > config.Capacity {
+				cost = config.Capacity
+			} else if cost < 0 {
+				cost = 0
+			}
+
+			key := ""
+			if config.Key != nil {
+				key = config.Key(req)
+			} else if h, _, err := net.SplitHostPort(
+				req.RemoteAddr,
+			); err == nil {
+				key = h
+			} else {
+				key = req.RemoteAddr
+			}
+
+			now := time.Now()
+
+			mutex.Lock()
+			if now.Sub(sweptAt) >= time.Minute {
+				sweepCostBuckets(buckets, config, now)
+				sweptAt = now
+			}
+
+			b, ok := buckets[key]
+			if !ok {
+				b = &costBucket{
+					tokens:    float64(config.Capacity),
+					updatedAt: now,
+				}
+				buckets[key] = b
+			}
+
+			elapsed := now.Sub(b.updatedAt).Seconds()
+			b.tokens += elapsed * config.RefillRate
+			if b.tokens > float64(config.Capacity) {
+				b.tokens = float64(config.Capacity)
+			}
+
+			b.updatedAt = now
+
+			if b.tokens < float64(cost) {
+				retryAfter := math.MaxInt32
+				if config.RefillRate > 0 {
+					retryAfter = int(math.Ceil(
+						(float64(cost) - b.tokens) /
+							config.RefillRate,
+					))
+				}
+
+				mutex.Unlock()
+
+				ra := strconv.Itoa(retryAfter)
+				res.Headers["Retry-After"] = ra
+
+				return &air.Error{
+					Code:    429,
+					Message: "Too Many Requests",
+				}
+			}
+
+			b.tokens -= float64(cost)
+			mutex.Unlock()
+
+			return next(req, res)
+		}
+	}
+}
+
+// sweepCostBuckets evicts the buckets that have been refilled to the
+// `CostConfig.Capacity` of the config by the now from the buckets.
+func sweepCostBuckets(
+	buckets map[string]*costBucket,
+	config CostConfig,
+	now time.Time,
+) {
+	if config.RefillRate <= 0 {
+		return
+	}
+
+	for k, b := range buckets {
+		idle := now.Sub(b.updatedAt).Seconds()
+		if b.tokens+idle*config.RefillRate >= float64(config.Capacity) {
+			delete(buckets, k)
+		}
+	}
+}
+
+// costBucket is a token bucket of the `CostLimit`.
+type costBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
@@ -0,0 +1,121 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCostLimit(t *testing.T) {
+	g := CostLimit(CostConfig{
+		Capacity:   6,
+		RefillRate: 0.5,
+		Cost: func(req *air.Request) int {
+			switch req.URL.Path {
+			case "/cost_limit/search":
+				return 5
+			case "/cost_limit/export":
+				return 10
+			case "/cost_limit/refund":
+				return -10
+			}
+			return 1
+		},
+	})
+
+	h := func(req *air.Request, res *air.Response) error {
+		return res.String("foobar")
+	}
+
+	air.GET("/cost_limit/ping", h, g)
+	air.GET("/cost_limit/search", h, g)
+	air.GET("/cost_limit/export", h, g)
+	air.GET("/cost_limit/refund", h, g)
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/cost_limit/ping", nil)
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		assert.Equal(t, 200, rec.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/cost_limit/search", nil)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 429, rec.Code)
+	assert.Equal(t, "4", rec.Header().Get("Retry-After"))
+
+	req = httptest.NewRequest("GET", "/cost_limit/ping", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	req = httptest.NewRequest("GET", "/cost_limit/search", nil)
+	req.RemoteAddr = "192.0.2.2:1234"
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	req = httptest.NewRequest("GET", "/cost_limit/export", nil)
+	req.RemoteAddr = "192.0.2.3:1234"
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	req = httptest.NewRequest("GET", "/cost_limit/export", nil)
+	req.RemoteAddr = "192.0.2.3:1234"
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 429, rec.Code)
+	assert.Equal(t, "12", rec.Header().Get("Retry-After"))
+
+	for _, r := range []struct {
+		path string
+		code int
+	}{
+		{"/cost_limit/refund", 200},
+		{"/cost_limit/export", 200},
+		{"/cost_limit/ping", 429},
+	} {
+		req = httptest.NewRequest("GET", r.path, nil)
+		req.RemoteAddr = "192.0.2.4:1234"
+		rec = httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		assert.Equal(t, r.code, rec.Code)
+	}
+
+	assert.Panics(t, func() {
+		CostLimit(CostConfig{
+			RefillRate: 1,
+		})
+	})
+}
+
+func TestSweepCostBuckets(t *testing.T) {
+	config := CostConfig{
+		Capacity:   6,
+		RefillRate: 0.5,
+	}
+
+	now := time.Now()
+	buckets := map[string]*costBucket{
+		"foo": {
+			tokens:    6,
+			updatedAt: now,
+		},
+		"bar": {
+			tokens:    0,
+			updatedAt: now,
+		},
+	}
+
+	sweepCostBuckets(buckets, config, now)
+	assert.Len(t, buckets, 1)
+	assert.NotNil(t, buckets["bar"])
+
+	sweepCostBuckets(buckets, config, now.Add(12*time.Second))
+	assert.Empty(t, buckets)
+}
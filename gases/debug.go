@@ -0,0 +1,91 @@
+package gases
+
+import (
+	"bytes"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+
+	"github.com/sheng/air"
+)
+
+// DebugConfig is the configuration of the `Debug`.
+type DebugConfig struct {
+	// Prefix is the path prefix that the debug endpoints are mounted under.
+	// It defaults to "/debug".
+	Prefix string
+
+	// Authorized reports whether the request is authorized to access the
+	// debug endpoints. All the requests are unauthorized when it is nil.
+	Authorized func(*air.Request) bool
+}
+
+// Debug returns a `air.Gas` that serves the runtime debug endpoints under the
+// `DebugConfig.Prefix` based on the config. It should be used as a pregas so
+// that the endpoints are served before the router. The unauthorized requests
+// are rejected with the 403.
+//
+// The endpoints are "{prefix}/goroutines" (the stack traces of all the
+// goroutines), "{prefix}/heap" (the heap statistics) and "{prefix}/build" (the
+// build information).
+func Debug(config DebugConfig) air.Gas {
+	if config.Prefix == "" {
+		config.Prefix = "/debug"
+	}
+
+	config.Prefix = strings.TrimSuffix(config.Prefix, "/")
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			p := req.URL.Path
+			if p != config.Prefix &&
+				!strings.HasPrefix(p, config.Prefix+"/") {
+				return next(req, res)
+			}
+
+			if config.Authorized == nil || !config.Authorized(req) {
+				return &air.Error{
+					Code:    403,
+					Message: "Forbidden",
+				}
+			}
+
+			switch p[len(config.Prefix):] {
+			case "/goroutines":
+				buf := &bytes.Buffer{}
+				err := pprof.Lookup("goroutine").WriteTo(buf, 2)
+				if err != nil {
+					return err
+				}
+				return res.String(buf.String())
+			case "/heap":
+				ms := &runtime.MemStats{}
+				runtime.ReadMemStats(ms)
+				return res.JSON(map[string]interface{}{
+					"alloc":         ms.Alloc,
+					"total_alloc":   ms.TotalAlloc,
+					"sys":           ms.Sys,
+					"heap_alloc":    ms.HeapAlloc,
+					"heap_sys":      ms.HeapSys,
+					"heap_idle":     ms.HeapIdle,
+					"heap_inuse":    ms.HeapInuse,
+					"heap_objects":  ms.HeapObjects,
+					"num_gc":        ms.NumGC,
+					"pause_total":   ms.PauseTotalNs,
+					"num_goroutine": runtime.NumGoroutine(),
+				})
+			case "/build":
+				return res.JSON(map[string]interface{}{
+					"app_name":   air.AppName,
+					"go_version": runtime.Version(),
+					"goos":       runtime.GOOS,
+					"goarch":     runtime.GOARCH,
+					"compiler":   runtime.Compiler,
+					"num_cpu":    runtime.NumCPU(),
+				})
+			}
+
+			return air.NotFoundHandler(req, res)
+		}
+	}
+}
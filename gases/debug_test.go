@@ -0,0 +1,69 @@
+package gases
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebug(t *testing.T) {
+	air.Pregases = append(air.Pregases, Debug(DebugConfig{
+		Prefix: "/debug_gas/",
+		Authorized: func(req *air.Request) bool {
+			return req.Headers["Authorization"] == "Bearer foobar"
+		},
+	}))
+
+	defer func() {
+		air.Pregases = air.Pregases[:len(air.Pregases)-1]
+	}()
+
+	req := httptest.NewRequest("GET", "/debug_gas/heap", nil)
+	req.Header.Set("Authorization", "Bearer foobar")
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	m := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &m))
+	assert.NotZero(t, m["heap_alloc"])
+
+	req = httptest.NewRequest("GET", "/debug_gas/build", nil)
+	req.Header.Set("Authorization", "Bearer foobar")
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	m = map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &m))
+	assert.Equal(t, runtime.Version(), m["go_version"])
+
+	req = httptest.NewRequest("GET", "/debug_gas/goroutines", nil)
+	req.Header.Set("Authorization", "Bearer foobar")
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.True(t, strings.Contains(rec.Body.String(), "goroutine"))
+
+	req = httptest.NewRequest("GET", "/debug_gas/foobar", nil)
+	req.Header.Set("Authorization", "Bearer foobar")
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 404, rec.Code)
+
+	req = httptest.NewRequest("GET", "/debug_gas/heap", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 403, rec.Code)
+
+	req = httptest.NewRequest("GET", "/debug_gas/heap", nil)
+	req.Header.Set("Authorization", "Bearer bar")
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 403, rec.Code)
+}
@@ -0,0 +1,107 @@
+package gases
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/sheng/air"
+)
+
+// Decrypt returns a `air.Gas` that decrypts the encrypted request bodies in
+// place before the subsequent handlers, so that they read the plaintext.
+//
+// An encrypted request is marked by an "X-Encryption" header naming the key
+// identifier and the algorithm (e.g. "keyid=foo; alg=A256GCM"), and its body is
+// the 12-byte nonce followed by the AES-GCM sealed ciphertext. The algorithm
+// must be one of the "A128GCM", "A192GCM" and "A256GCM", and the key is looked
+// up by the keyProvider. The requests failed to be decrypted are rejected with
+// the 400, and the requests without the header pass through.
+func Decrypt(keyProvider func(keyID string) ([]byte, error)) air.Gas {
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			h, ok := req.Headers["X-Encryption"]
+			if !ok {
+				return next(req, res)
+			}
+
+			keyID, alg := "", ""
+			for _, p := range strings.Split(h, ";") {
+				k, v := p, ""
+				if i := strings.Index(p, "="); i >= 0 {
+					k, v = p[:i], p[i+1:]
+				}
+
+				switch strings.TrimSpace(k) {
+				case "keyid":
+					keyID = strings.TrimSpace(v)
+				case "alg":
+					alg = strings.TrimSpace(v)
+				}
+			}
+
+			e := &air.Error{
+				Code:    400,
+				Message: "Bad Request",
+			}
+
+			keySize := 0
+			switch alg {
+			case "A128GCM":
+				keySize = 16
+			case "A192GCM":
+				keySize = 24
+			case "A256GCM":
+				keySize = 32
+			default:
+				return e
+			}
+
+			key, err := keyProvider(keyID)
+			if err != nil || len(key) != keySize {
+				return e
+			}
+
+			block, err := aes.NewCipher(key)
+			if err != nil {
+				return e
+			}
+
+			aead, err := cipher.NewGCM(block)
+			if err != nil {
+				return err
+			}
+
+			var b []byte
+			if req.Body != nil {
+				b, err = ioutil.ReadAll(req.Body)
+				if err != nil {
+					return err
+				}
+			}
+
+			ns := aead.NonceSize()
+			if len(b) < ns {
+				return e
+			}
+
+			b, err = aead.Open(nil, b[:ns], b[ns:], nil)
+			if err != nil {
+				return e
+			}
+
+			req.Body = bytes.NewReader(b)
+			req.ContentLength = int64(len(b))
+
+			cl := strconv.Itoa(len(b))
+			if _, ok := req.Headers["Content-Length"]; ok {
+				req.Headers["Content-Length"] = cl
+			}
+
+			return next(req, res)
+		}
+	}
+}
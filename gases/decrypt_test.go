@@ -0,0 +1,77 @@
+package gases
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecrypt(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	air.POST(
+		"/decrypt",
+		func(req *air.Request, res *air.Response) error {
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			return res.String(string(b))
+		},
+		Decrypt(func(keyID string) ([]byte, error) {
+			if keyID != "foo" {
+				return nil, errors.New("unknown key")
+			}
+			return key, nil
+		}),
+	)
+
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	nonce := []byte("0123456789ab")
+	body := aead.Seal(nonce, nonce, []byte("foobar"), nil)
+
+	do := func(header string, body []byte) (int, string) {
+		req := httptest.NewRequest(
+			"POST",
+			"/decrypt",
+			bytes.NewReader(body),
+		)
+		if header != "" {
+			req.Header.Set("X-Encryption", header)
+		}
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	code, b := do("keyid=foo; alg=A256GCM", body)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "foobar", b)
+
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-1] ^= 1
+
+	code, _ = do("keyid=foo; alg=A256GCM", tampered)
+	assert.Equal(t, 400, code)
+
+	code, _ = do("keyid=bar; alg=A256GCM", body)
+	assert.Equal(t, 400, code)
+
+	code, _ = do("keyid=foo; alg=A128GCM", body)
+	assert.Equal(t, 400, code)
+
+	code, _ = do("keyid=foo; alg=A256GCM", nonce[:4])
+	assert.Equal(t, 400, code)
+
+	code, b = do("", []byte("foobar"))
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "foobar", b)
+}
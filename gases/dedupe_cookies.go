@@ -0,0 +1,133 @@
+package gases
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/sheng/air"
+)
+
+// DedupeCookies returns a `air.Gas` that coalesces the "Set-Cookie" headers of
+// the responses right before they are sent. For the cookies with identical
+// names, paths and domains, only the final one is kept, so the cookies set by
+// the later gases or handlers win. The order of the kept cookies is respected.
+func DedupeCookies() air.Gas {
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			rw := res.HTTPResponseWriter()
+			res.SetHTTPResponseWriter(&dedupeCookiesWriter{
+				ResponseWriter: rw,
+			})
+			defer res.SetHTTPResponseWriter(rw)
+
+			return next(req, res)
+		}
+	}
+}
+
+// dedupeCookiesWriter is an `http.ResponseWriter` that dedupes the
+// "Set-Cookie" headers before writing the header.
+type dedupeCookiesWriter struct {
+	http.ResponseWriter
+
+	wroteHeader bool
+}
+
+// WriteHeader implements the `http.ResponseWriter`.
+func (dcw *dedupeCookiesWriter) WriteHeader(statusCode int) {
+	if !dcw.wroteHeader {
+		dcw.wroteHeader = true
+
+		h := dcw.Header()
+		if scs := h["Set-Cookie"]; len(scs) > 1 {
+			seen := map[string]bool{}
+			kept := make([]string, 0, len(scs))
+			for i := len(scs) - 1; i >= 0; i-- {
+				k := dedupeCookiesKey(scs[i])
+				if !seen[k] {
+					seen[k] = true
+					kept = append(kept, scs[i])
+				}
+			}
+
+			for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+				kept[i], kept[j] = kept[j], kept[i]
+			}
+
+			h["Set-Cookie"] = kept
+		}
+	}
+
+	dcw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write implements the `http.ResponseWriter`.
+func (dcw *dedupeCookiesWriter) Write(b []byte) (int, error) {
+	dcw.WriteHeader(200)
+	return dcw.ResponseWriter.Write(b)
+}
+
+// Flush implements the `http.Flusher`.
+func (dcw *dedupeCookiesWriter) Flush() {
+	if f, ok := dcw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements the `http.Hijacker`.
+func (dcw *dedupeCookiesWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := dcw.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// CloseNotify implements the `http.CloseNotifier`.
+func (dcw *dedupeCookiesWriter) CloseNotify() <-chan bool {
+	if cn, ok := dcw.ResponseWriter.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return nil
+}
+
+// Push implements the `http.Pusher`.
+func (dcw *dedupeCookiesWriter) Push(
+	target string,
+	opts *http.PushOptions,
+) error {
+	if p, ok := dcw.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// dedupeCookiesKey returns the key that identifies the cookie of the
+// "Set-Cookie" header value sc, which consists of its name, path and domain.
+func dedupeCookiesKey(sc string) string {
+	parts := strings.Split(sc, ";")
+
+	name := strings.TrimSpace(parts[0])
+	if i := strings.Index(name, "="); i >= 0 {
+		name = name[:i]
+	}
+
+	path, domain := "", ""
+	for _, p := range parts[1:] {
+		k, v := strings.TrimSpace(p), ""
+		if i := strings.Index(k, "="); i >= 0 {
+			v = strings.TrimSpace(k[i+1:])
+			k = strings.TrimSpace(k[:i])
+		}
+
+		switch strings.ToLower(k) {
+		case "path":
+			path = v
+		case "domain":
+			domain = strings.TrimPrefix(strings.ToLower(v), ".")
+		}
+	}
+
+	return name + "\x00" + path + "\x00" + domain
+}
@@ -0,0 +1,74 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupeCookies(t *testing.T) {
+	setCookie := func(name, value, path string) air.Gas {
+		return func(next air.Handler) air.Handler {
+			return func(req *air.Request, res *air.Response) error {
+				res.Cookies = append(res.Cookies, &air.Cookie{
+					Name:  name,
+					Value: value,
+					Path:  path,
+				})
+				return next(req, res)
+			}
+		}
+	}
+
+	air.GET(
+		"/dedupe_cookies",
+		func(req *air.Request, res *air.Response) error {
+			return res.String("foobar")
+		},
+		DedupeCookies(),
+		setCookie("session", "foo", ""),
+		setCookie("theme", "dark", ""),
+		setCookie("session", "foo", "/admin"),
+		setCookie("session", "bar", ""),
+	)
+
+	req := httptest.NewRequest("GET", "/dedupe_cookies", nil)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "foobar", rec.Body.String())
+	assert.Equal(
+		t,
+		[]string{
+			"theme=dark",
+			"session=foo; Path=/admin",
+			"session=bar",
+		},
+		rec.Header()["Set-Cookie"],
+	)
+}
+
+func TestDedupeCookiesKey(t *testing.T) {
+	assert.Equal(
+		t,
+		dedupeCookiesKey("foo=bar"),
+		dedupeCookiesKey("foo=baz; HttpOnly"),
+	)
+	assert.Equal(
+		t,
+		dedupeCookiesKey("foo=bar; Path=/; Domain=example.com"),
+		dedupeCookiesKey("foo=baz; domain=.EXAMPLE.com; path=/"),
+	)
+	assert.NotEqual(
+		t,
+		dedupeCookiesKey("foo=bar; Path=/"),
+		dedupeCookiesKey("foo=bar; Path=/foo"),
+	)
+	assert.NotEqual(
+		t,
+		dedupeCookiesKey("foo=bar"),
+		dedupeCookiesKey("foo=bar; Domain=example.com"),
+	)
+}
@@ -0,0 +1,102 @@
+package gases
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"strconv"
+
+	"github.com/sheng/air"
+)
+
+// EnvelopeConfig is the configuration of the `Envelope`.
+type EnvelopeConfig struct {
+	// Meta returns the "meta" member of the enveloped success responses. The
+	// "meta" member is omitted when it is nil or returns nil.
+	Meta func(*air.Request, *air.Response) map[string]interface{}
+}
+
+// Envelope returns a `air.Gas` that wraps the "application/json" responses into
+// a standard structure based on the config.
+//
+// The success responses are wrapped as `{"data": ..., "meta": {...}}` and the
+// error responses (including the errors returned by the subsequent handlers)
+// are wrapped as `{"error": {...}}`. The errors returned by the subsequent
+// handlers are only wrapped for the requests that prefer JSON over HTML based
+// on their "Accept" header, the others are left to the `air.ErrorHandler`. The
+// responses that are not "application/json" or already enveloped pass through.
+func Envelope(config EnvelopeConfig) air.Gas {
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			rw := res.HTTPResponseWriter()
+			rr := newResponseRecorder(rw)
+			res.SetHTTPResponseWriter(rr)
+			err := next(req, res)
+			res.SetHTTPResponseWriter(rw)
+
+			if !res.Written {
+				if err == nil ||
+					!acceptsJSON(req.Headers["Accept"]) {
+					return err
+				}
+
+				e := httpError(err)
+
+				air.ERROR(err)
+
+				res.StatusCode = e.Code
+				return res.JSON(map[string]interface{}{
+					"error": map[string]interface{}{
+						"code":    e.Code,
+						"message": e.Message,
+					},
+				})
+			}
+
+			b := rr.body.Bytes()
+			mt, _, _ := mime.ParseMediaType(rr.header.Get("Content-Type"))
+			if mt == "application/json" && len(b) > 0 && !enveloped(b) {
+				m := map[string]interface{}{}
+				if rr.statusCode >= 400 {
+					m["error"] = json.RawMessage(b)
+				} else {
+					m["data"] = json.RawMessage(b)
+					if config.Meta != nil {
+						if meta := config.Meta(req, res); meta != nil {
+							m["meta"] = meta
+						}
+					}
+				}
+
+				nb, merr := json.Marshal(m)
+				if merr != nil {
+					return merr
+				}
+
+				b = nb
+				rr.header.Set("Content-Length", strconv.Itoa(len(b)))
+			}
+
+			if ferr := rr.flush(rw, b); ferr != nil {
+				return ferr
+			}
+
+			return err
+		}
+	}
+}
+
+// enveloped reports whether the JSON b is an object that has already been
+// enveloped.
+func enveloped(b []byte) bool {
+	if b = bytes.TrimSpace(b); len(b) == 0 || b[0] != '{' {
+		return false
+	}
+	m := map[string]json.RawMessage{}
+	if json.Unmarshal(b, &m) != nil {
+		return false
+	}
+	_, hasData := m["data"]
+	_, hasError := m["error"]
+	return hasData || hasError
+}
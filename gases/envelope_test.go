@@ -0,0 +1,119 @@
+package gases
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelope(t *testing.T) {
+	g := Envelope(EnvelopeConfig{
+		Meta: func(*air.Request, *air.Response) map[string]interface{} {
+			return map[string]interface{}{
+				"version": 1,
+			}
+		},
+	})
+
+	air.GET(
+		"/envelope/data",
+		func(req *air.Request, res *air.Response) error {
+			return res.JSON(map[string]string{
+				"foo": "bar",
+			})
+		},
+		g,
+	)
+
+	air.GET(
+		"/envelope/error",
+		func(req *air.Request, res *air.Response) error {
+			return &air.Error{
+				Code:    404,
+				Message: "Not Found",
+			}
+		},
+		g,
+	)
+
+	air.GET(
+		"/envelope/unknown",
+		func(req *air.Request, res *air.Response) error {
+			return errors.New("foobar")
+		},
+		g,
+	)
+
+	air.GET(
+		"/envelope/text",
+		func(req *air.Request, res *air.Response) error {
+			return res.String("foobar")
+		},
+		g,
+	)
+
+	air.GET(
+		"/envelope/enveloped",
+		func(req *air.Request, res *air.Response) error {
+			return res.JSON(map[string]string{
+				"data": "foobar",
+			})
+		},
+		g,
+	)
+
+	req := httptest.NewRequest("GET", "/envelope/data", nil)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(
+		t,
+		`{"data":{"foo":"bar"},"meta":{"version":1}}`,
+		rec.Body.String(),
+	)
+	assert.Equal(t, "43", rec.Header().Get("Content-Length"))
+
+	req = httptest.NewRequest("GET", "/envelope/error", nil)
+	req.Header.Set("Accept", "application/json")
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 404, rec.Code)
+	assert.Equal(
+		t,
+		`{"error":{"code":404,"message":"Not Found"}}`,
+		rec.Body.String(),
+	)
+
+	req = httptest.NewRequest("GET", "/envelope/unknown", nil)
+	req.Header.Set("Accept", "application/json")
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 500, rec.Code)
+	assert.Equal(
+		t,
+		`{"error":{"code":500,"message":"Internal Server Error"}}`,
+		rec.Body.String(),
+	)
+
+	req = httptest.NewRequest("GET", "/envelope/error", nil)
+	req.Header.Set("Accept", "text/html")
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 404, rec.Code)
+	assert.Equal(t, "Not Found", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/envelope/text", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "foobar", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/envelope/enveloped", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, `{"data":"foobar"}`, rec.Body.String())
+}
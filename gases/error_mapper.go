@@ -0,0 +1,64 @@
+package gases
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/sheng/air"
+)
+
+// ErrorMapping is a mapping from an error to a status code used by the
+// `ErrorMapper`.
+type ErrorMapping struct {
+	// Err is the error to be mapped.
+	Err error
+
+	// Code is the status code that the `Err` is mapped to.
+	Code int
+}
+
+// ErrorMapper returns a `air.Gas` that maps the errors returned by the
+// subsequent handlers to the status codes based on the mappings and responds
+// them as `{"message": ..., "code": ...}`. The errors are compared by using the
+// `errors.Is()`, so the wrapped errors are mapped as well, and the first
+// matching mapping wins. The unmapped errors are responded with their own
+// status codes if they are `air.Error`s, or the 500 otherwise.
+func ErrorMapper(mappings ...ErrorMapping) air.Gas {
+	return ErrorMapperFunc(func(err error) (int, bool) {
+		for _, m := range mappings {
+			if errors.Is(err, m.Err) {
+				return m.Code, true
+			}
+		}
+		return 0, false
+	})
+}
+
+// ErrorMapperFunc is like the `ErrorMapper`, but maps the errors by using the
+// mapper, which returns the status code of an error and reports whether the
+// error is mapped.
+func ErrorMapperFunc(mapper func(error) (int, bool)) air.Gas {
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			err := next(req, res)
+			if err == nil || res.Written {
+				return err
+			}
+
+			air.ERROR(err)
+
+			e := httpError(err)
+			if c, ok := mapper(err); ok {
+				e = &air.Error{
+					Code:    c,
+					Message: http.StatusText(c),
+				}
+				if air.DebugMode {
+					e.Message = err.Error()
+				}
+			}
+
+			return respondJSONError(req, res, e)
+		}
+	}
+}
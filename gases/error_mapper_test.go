@@ -0,0 +1,104 @@
+package gases
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errErrorMapperTestNoRows   = errors.New("no rows in result set")
+	errErrorMapperTestConflict = fmt.Errorf(
+		"conflict: %w",
+		errErrorMapperTestNoRows,
+	)
+)
+
+func TestErrorMapper(t *testing.T) {
+	g := ErrorMapper(
+		ErrorMapping{
+			Err:  errErrorMapperTestConflict,
+			Code: 409,
+		},
+		ErrorMapping{
+			Err:  errErrorMapperTestNoRows,
+			Code: 404,
+		},
+	)
+
+	air.GET(
+		"/error_mapper/mapped",
+		func(req *air.Request, res *air.Response) error {
+			return fmt.Errorf("find: %w", errErrorMapperTestNoRows)
+		},
+		g,
+	)
+
+	air.GET(
+		"/error_mapper/ordered",
+		func(req *air.Request, res *air.Response) error {
+			err := errErrorMapperTestConflict
+			return fmt.Errorf("save: %w", err)
+		},
+		g,
+	)
+
+	air.GET(
+		"/error_mapper/unmapped",
+		func(req *air.Request, res *air.Response) error {
+			return errors.New("foobar")
+		},
+		g,
+	)
+
+	air.GET(
+		"/error_mapper/func",
+		func(req *air.Request, res *air.Response) error {
+			return errors.New("foobar")
+		},
+		ErrorMapperFunc(func(err error) (int, bool) {
+			return 409, err.Error() == "foobar"
+		}),
+	)
+
+	req := httptest.NewRequest("GET", "/error_mapper/mapped", nil)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 404, rec.Code)
+	assert.Equal(
+		t,
+		`{"code":404,"message":"Not Found"}`,
+		rec.Body.String(),
+	)
+
+	for i := 0; i < 10; i++ {
+		req = httptest.NewRequest("GET", "/error_mapper/ordered", nil)
+		rec = httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		assert.Equal(t, 409, rec.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/error_mapper/unmapped", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 500, rec.Code)
+	assert.Equal(
+		t,
+		`{"code":500,"message":"Internal Server Error"}`,
+		rec.Body.String(),
+	)
+
+	req = httptest.NewRequest("GET", "/error_mapper/func", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 409, rec.Code)
+	assert.Equal(
+		t,
+		`{"code":409,"message":"Conflict"}`,
+		rec.Body.String(),
+	)
+}
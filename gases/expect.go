@@ -0,0 +1,64 @@
+package gases
+
+import (
+	"io"
+
+	"github.com/sheng/air"
+)
+
+// ExpectConfig is the configuration of the `Expect`.
+type ExpectConfig struct {
+	// MaxContentLength is the maximum content length of the requests that
+	// the clients are let to continue sending. The requests of unknown
+	// content length (e.g. the chunked ones) are rejected when it is set.
+	// It is not limited when it is less than or equal to zero.
+	MaxContentLength int64
+
+	// Checks is the optional preliminary checks that the requests must pass
+	// before their clients are let to continue sending.
+	Checks []func(*air.Request) bool
+}
+
+// Expect returns a `air.Gas` that handles the handshake of the requests whose
+// clients expect the 100 Continue (see `air.Request#ExpectsContinue()`) based
+// on the config. The requests that pass the preliminary checks are signaled
+// the 100 Continue right away, and the others are rejected with the 417
+// without reading their bodies.
+//
+// The body form data of the requests are parsed after all the gases have run
+// (see `air.Request#ParseForm()`), so the form uploads are not read before
+// the `Expect` rejects them.
+func Expect(config ExpectConfig) air.Gas {
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			if !req.ExpectsContinue() {
+				return next(req, res)
+			}
+
+			mcl := config.MaxContentLength
+			passed := mcl <= 0 ||
+				(req.ContentLength >= 0 &&
+					req.ContentLength <= mcl)
+			for _, c := range config.Checks {
+				passed = passed && c(req)
+			}
+
+			if !passed {
+				return &air.Error{
+					Code:    417,
+					Message: "Expectation Failed",
+				}
+			}
+
+			// The 100 Continue is sent on the first body read.
+			if req.Body != nil {
+				_, err := req.Body.Read(nil)
+				if err != nil && err != io.EOF {
+					return err
+				}
+			}
+
+			return next(req, res)
+		}
+	}
+}
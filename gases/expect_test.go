@@ -0,0 +1,111 @@
+package gases
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpect(t *testing.T) {
+	air.POST(
+		"/expect",
+		func(req *air.Request, res *air.Response) error {
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			return res.String(string(b))
+		},
+		Expect(ExpectConfig{
+			MaxContentLength: 10,
+		}),
+	)
+
+	air.POST(
+		"/expect/form",
+		func(req *air.Request, res *air.Response) error {
+			return res.String(req.Params["foo"])
+		},
+		Expect(ExpectConfig{
+			MaxContentLength: 10,
+		}),
+	)
+
+	s := httptest.NewServer(http.HandlerFunc(air.ServeHTTP))
+	defer s.Close()
+
+	c, err := net.Dial("tcp", s.Listener.Addr().String())
+	assert.NoError(t, err)
+	defer c.Close()
+
+	br := bufio.NewReader(c)
+
+	_, err = c.Write([]byte("POST /expect HTTP/1.1\r\nHost: foobar\r\n" +
+		"Content-Length: 6\r\nExpect: 100-continue\r\n\r\n"))
+	assert.NoError(t, err)
+
+	l, err := br.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 100 Continue\r\n", l)
+
+	_, err = c.Write([]byte("foobar"))
+	assert.NoError(t, err)
+
+	_, err = br.ReadString('\n')
+	assert.NoError(t, err)
+
+	res, err := http.ReadResponse(br, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	b, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "foobar", string(b))
+
+	_, err = c.Write([]byte("POST /expect/form HTTP/1.1\r\n" +
+		"Host: foobar\r\nContent-Length: 15\r\n" +
+		"Content-Type: application/x-www-form-urlencoded\r\n" +
+		"Expect: 100-continue\r\n\r\n"))
+	assert.NoError(t, err)
+
+	res, err = http.ReadResponse(br, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 417, res.StatusCode)
+	res.Body.Close()
+
+	req := httptest.NewRequest(
+		"POST",
+		"/expect",
+		strings.NewReader("foobarfoobar"),
+	)
+	req.Header.Set("Expect", "100-continue")
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 417, rec.Code)
+
+	req = httptest.NewRequest(
+		"POST",
+		"/expect",
+		strings.NewReader("foobar"),
+	)
+	req.Header.Set("Expect", "100-continue")
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 417, rec.Code)
+
+	req = httptest.NewRequest(
+		"POST",
+		"/expect",
+		strings.NewReader("foobarfoobar"),
+	)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "foobarfoobar", rec.Body.String())
+}
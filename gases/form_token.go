@@ -0,0 +1,210 @@
+package gases
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sheng/air"
+)
+
+// FormTokenConfig is the configuration of the `FormToken`.
+type FormTokenConfig struct {
+	// Secret is the secret that the tokens and the client cookies are
+	// signed with. It cannot be empty.
+	Secret string
+
+	// Name is the name of the form field that the token is submitted with.
+	// The "form_token" is used when it is empty.
+	Name string
+
+	// CookieName is the name of the signed cookie that identifies the
+	// client. The "form_token_client" is used when it is empty.
+	CookieName string
+
+	// TTL is the duration a token remains valid after it is issued. The one
+	// hour is used when it is less than or equal to zero.
+	TTL time.Duration
+}
+
+// FormToken returns a `air.Gas` that protects the form submissions from being
+// forged across sites or replayed by the single-use tokens based on the
+// config.
+//
+// Each client is identified by a random ID kept in the signed cookie named the
+// `FormTokenConfig.CookieName`, which is set on the first safe request (see
+// `air.Request#IsSafe()`) of the client. Each safe request is issued a new
+// token bound to its client, stored in the `air.Request.Values` with the key
+// "form_token" for rendering into the form. Each unsafe request must submit an
+// unexpired token issued to the same client before, by the form field or the
+// "X-Form-Token" header. The token is consumed by the submission, so the
+// missing, expired, reused or foreign ones are rejected with the 403.
+//
+// The tokens are signed rather than stored, so issuing them costs no memory.
+// Only the consumed ones are remembered until they expire, and the expired
+// ones are forgotten at most once per the `FormTokenConfig.TTL`.
+func FormToken(config FormTokenConfig) air.Gas {
+	if config.Secret == "" {
+		panic("gases: the form token secret cannot be empty")
+	}
+
+	if config.Name == "" {
+		config.Name = "form_token"
+	}
+
+	if config.CookieName == "" {
+		config.CookieName = "form_token_client"
+	}
+
+	if config.TTL <= 0 {
+		config.TTL = time.Hour
+	}
+
+	mutex := &sync.Mutex{}
+	consumed := map[string]time.Time{}
+	sweptAt := time.Now()
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			now := time.Now()
+
+			client, cerr := req.SignedCookie(
+				config.CookieName,
+				config.Secret,
+			)
+
+			if req.IsSafe() {
+				if cerr != nil || client == "" {
+					client, cerr = randomHex(16)
+					if cerr != nil {
+						return cerr
+					}
+
+					res.Cookies = append(
+						res.Cookies,
+						formTokenCookie(config, client),
+					)
+				}
+
+				t, err := formTokenIssue(config, client, now)
+				if err != nil {
+					return err
+				}
+
+				req.Values["form_token"] = t
+
+				return next(req, res)
+			}
+
+			if err := req.ParseForm(); err != nil {
+				return &air.Error{
+					Code:    400,
+					Message: "Bad Request",
+				}
+			}
+
+			t := req.Params[config.Name]
+			if t == "" {
+				t = req.Headers["X-Form-Token"]
+			}
+
+			forbidden := &air.Error{
+				Code:    403,
+				Message: "Forbidden",
+			}
+
+			parts := strings.Split(t, ".")
+			if cerr != nil || client == "" || len(parts) != 3 {
+				return forbidden
+			}
+
+			p := parts[0] + "." + parts[1]
+			sig := formTokenSign(config.Secret, client+"."+p)
+			if !hmac.Equal([]byte(parts[2]), []byte(sig)) {
+				return forbidden
+			}
+
+			exp, err := strconv.ParseInt(parts[1], 10, 64)
+			expiresAt := time.Unix(exp, 0)
+			if err != nil || !now.Before(expiresAt) {
+				return forbidden
+			}
+
+			mutex.Lock()
+			if now.Sub(sweptAt) >= config.TTL {
+				for k, e := range consumed {
+					if !now.Before(e) {
+						delete(consumed, k)
+					}
+				}
+
+				sweptAt = now
+			}
+
+			_, reused := consumed[parts[0]]
+			if !reused {
+				consumed[parts[0]] = expiresAt
+			}
+			mutex.Unlock()
+
+			if reused {
+				return forbidden
+			}
+
+			return next(req, res)
+		}
+	}
+}
+
+// formTokenCookie returns the signed cookie that identifies the client based
+// on the config.
+func formTokenCookie(config FormTokenConfig, client string) *air.Cookie {
+	return &air.Cookie{
+		Name:     config.CookieName,
+		Value:    client + "|" + formTokenSign(config.Secret, client),
+		Path:     "/",
+		HTTPOnly: true,
+	}
+}
+
+// formTokenIssue returns a new token bound to the client that expires after
+// the `FormTokenConfig.TTL` of the config from the now. The token is in the
+// form "nonce.expiry.signature".
+func formTokenIssue(
+	config FormTokenConfig,
+	client string,
+	now time.Time,
+) (string, error) {
+	nonce, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	p := nonce + "." + strconv.FormatInt(now.Add(config.TTL).Unix(), 10)
+
+	return p + "." + formTokenSign(config.Secret, client+"."+p), nil
+}
+
+// formTokenSign returns the hex-encoded HMAC-SHA256 of the s keyed by the
+// secret, which is also the signature that the `air.Request#SignedCookie()`
+// expects.
+func formTokenSign(secret, s string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// randomHex returns the n random bytes encoded in hex.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
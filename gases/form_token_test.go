@@ -0,0 +1,80 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormToken(t *testing.T) {
+	g := FormToken(FormTokenConfig{
+		Secret: "secret",
+	})
+	h := func(req *air.Request, res *air.Response) error {
+		return res.String(req.GetString("form_token"))
+	}
+
+	air.GET("/form_token", h, g)
+	air.POST("/form_token", h, g)
+
+	issue := func(cookie string) (string, string) {
+		req := httptest.NewRequest("GET", "/form_token", nil)
+		if cookie != "" {
+			req.Header.Set("Cookie", cookie)
+		}
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		assert.Equal(t, 200, rec.Code)
+		if c := rec.Header().Get("Set-Cookie"); c != "" {
+			cookie = strings.SplitN(c, ";", 2)[0]
+		}
+		return rec.Body.String(), cookie
+	}
+
+	post := func(token, cookie string) int {
+		req := httptest.NewRequest(
+			"POST",
+			"/form_token",
+			strings.NewReader("form_token="+token),
+		)
+		req.Header.Set(
+			"Content-Type",
+			"application/x-www-form-urlencoded",
+		)
+		req.Header.Set("Cookie", cookie)
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	token, cookie := issue("")
+	assert.True(t, strings.HasPrefix(cookie, "form_token_client="))
+	assert.Len(t, strings.Split(token, "."), 3)
+
+	assert.Equal(t, 200, post(token, cookie))
+	assert.Equal(t, 403, post(token, cookie))
+	assert.Equal(t, 403, post("", cookie))
+
+	token, cookie2 := issue(cookie)
+	assert.Equal(t, cookie, cookie2)
+
+	_, foreign := issue("")
+	assert.NotEqual(t, cookie, foreign)
+	assert.Equal(t, 403, post(token, foreign))
+	assert.Equal(t, 403, post(token, ""))
+	assert.Equal(t, 403, post(token, cookie+"0"))
+
+	req := httptest.NewRequest("POST", "/form_token", nil)
+	req.Header.Set("X-Form-Token", token)
+	req.Header.Set("Cookie", cookie)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	assert.Panics(t, func() {
+		FormToken(FormTokenConfig{})
+	})
+}
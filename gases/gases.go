@@ -0,0 +1,111 @@
+// Package gases provides a set of commonly used `air.Gas` implementations.
+package gases
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/sheng/air"
+)
+
+// responseRecorder is an `http.ResponseWriter` that records what the handlers
+// respond instead of sending it to the client. The HTTP/2 server pushes and the
+// close notifications are passed through to the wrapped `http.ResponseWriter`.
+type responseRecorder struct {
+	rw          http.ResponseWriter
+	header      http.Header
+	statusCode  int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+// newResponseRecorder returns a new instance of the `responseRecorder` that
+// wraps the rw. The rw may be nil.
+func newResponseRecorder(rw http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{
+		rw:         rw,
+		header:     http.Header{},
+		statusCode: 200,
+	}
+}
+
+// Header implements the `http.ResponseWriter`.
+func (rr *responseRecorder) Header() http.Header {
+	return rr.header
+}
+
+// WriteHeader implements the `http.ResponseWriter`.
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	if !rr.wroteHeader {
+		rr.statusCode = statusCode
+		rr.wroteHeader = true
+	}
+}
+
+// Write implements the `http.ResponseWriter`.
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.WriteHeader(200)
+	return rr.body.Write(b)
+}
+
+// Flush implements the `http.Flusher`. It does nothing since the response is
+// recorded rather than sent.
+func (rr *responseRecorder) Flush() {}
+
+// Push implements the `http.Pusher`.
+func (rr *responseRecorder) Push(target string, opts *http.PushOptions) error {
+	if p, ok := rr.rw.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// CloseNotify implements the `http.CloseNotifier`.
+func (rr *responseRecorder) CloseNotify() <-chan bool {
+	if cn, ok := rr.rw.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return nil
+}
+
+// flush sends the recorded response to the rw with the b as the body.
+func (rr *responseRecorder) flush(rw http.ResponseWriter, b []byte) error {
+	for k, v := range rr.header {
+		rw.Header()[k] = v
+	}
+	rw.WriteHeader(rr.statusCode)
+	_, err := rw.Write(b)
+	return err
+}
+
+// httpError returns the `air.Error` that the err stands for in the same way as
+// the `air.ErrorHandler`.
+func httpError(err error) *air.Error {
+	if e, ok := err.(*air.Error); ok {
+		return e
+	}
+
+	e := &air.Error{
+		Code:    500,
+		Message: "Internal Server Error",
+	}
+	if air.DebugMode {
+		e.Message = err.Error()
+	}
+
+	return e
+}
+
+// Skipper defines a function to report whether a gas should be skipped for the
+// request.
+type Skipper func(*air.Request) bool
+
+// skipped reports whether any of the skippers skips the req.
+func skipped(skippers []Skipper, req *air.Request) bool {
+	for _, s := range skippers {
+		if s(req) {
+			return true
+		}
+	}
+	return false
+}
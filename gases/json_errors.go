@@ -0,0 +1,80 @@
+package gases
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/sheng/air"
+)
+
+// JSONErrors returns a `air.Gas` that renders the errors returned by the
+// subsequent handlers as `{"message": ..., "code": ...}` with the proper status
+// code for the requests that prefer JSON over HTML based on their "Accept"
+// header. The errors of the other requests are left to the
+// `air.ErrorHandler`.
+func JSONErrors() air.Gas {
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			err := next(req, res)
+			if err == nil || res.Written ||
+				!acceptsJSON(req.Headers["Accept"]) {
+				return err
+			}
+
+			air.ERROR(err)
+
+			return respondJSONError(req, res, httpError(err))
+		}
+	}
+}
+
+// respondJSONError responds the e to the client as
+// `{"message": ..., "code": ...}`.
+func respondJSONError(req *air.Request, res *air.Response, e *air.Error) error {
+	res.StatusCode = e.Code
+	if req.Method == "GET" || req.Method == "HEAD" {
+		delete(res.Headers, "ETag")
+		delete(res.Headers, "Last-Modified")
+	}
+
+	return res.JSON(map[string]interface{}{
+		"message": e.Message,
+		"code":    e.Code,
+	})
+}
+
+// acceptsJSON reports whether the accept prefers JSON over HTML. The wildcard
+// "*/*" is ignored since it prefers neither of them.
+func acceptsJSON(accept string) bool {
+	jsonQ, htmlQ := 0.0, 0.0
+	for _, ar := range strings.Split(accept, ",") {
+		mt, ps, err := mime.ParseMediaType(strings.TrimSpace(ar))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := ps["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		switch {
+		case mt == "application/json",
+			mt == "application/*",
+			strings.HasPrefix(mt, "application/") &&
+				strings.HasSuffix(mt, "+json"):
+			if q > jsonQ {
+				jsonQ = q
+			}
+		case mt == "text/html", mt == "text/*":
+			if q > htmlQ {
+				htmlQ = q
+			}
+		}
+	}
+
+	return jsonQ > 0 && jsonQ >= htmlQ
+}
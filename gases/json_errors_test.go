@@ -0,0 +1,63 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONErrors(t *testing.T) {
+	air.GET(
+		"/json_errors",
+		func(req *air.Request, res *air.Response) error {
+			return &air.Error{
+				Code:    404,
+				Message: "Not Found",
+			}
+		},
+		JSONErrors(),
+	)
+
+	req := httptest.NewRequest("GET", "/json_errors", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 404, rec.Code)
+	assert.Equal(
+		t,
+		"application/json; charset=utf-8",
+		rec.Header().Get("Content-Type"),
+	)
+	assert.Equal(
+		t,
+		`{"code":404,"message":"Not Found"}`,
+		rec.Body.String(),
+	)
+
+	req = httptest.NewRequest("GET", "/json_errors", nil)
+	req.Header.Set(
+		"Accept",
+		"text/html,application/xhtml+xml,application/xml;q=0.9,"+
+			"*/*;q=0.8",
+	)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 404, rec.Code)
+	assert.Equal(
+		t,
+		"text/plain; charset=utf-8",
+		rec.Header().Get("Content-Type"),
+	)
+	assert.Equal(t, "Not Found", rec.Body.String())
+}
+
+func TestAcceptsJSON(t *testing.T) {
+	assert.True(t, acceptsJSON("application/json"))
+	assert.True(t, acceptsJSON("application/vnd.api+json"))
+	assert.True(t, acceptsJSON("text/html;q=0.5, application/json"))
+	assert.False(t, acceptsJSON("text/html, application/json;q=0.5"))
+	assert.False(t, acceptsJSON("*/*"))
+	assert.False(t, acceptsJSON(""))
+}
@@ -0,0 +1,119 @@
+package gases
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+
+	"github.com/sheng/air"
+)
+
+// JSONGuard returns a `air.Gas` that rejects the "application/json" requests
+// whose body is nested deeper than the maxDepth or has more keys and elements
+// in total than the maxKeys with the 400 before the subsequent handlers
+// unmarshal it. The limit is disabled when it is less than or equal to zero.
+//
+// The request body is streamed through a decoder, so the rejection happens as
+// soon as a limit is exceeded. The request body remains readable afterward.
+func JSONGuard(maxDepth, maxKeys int) air.Gas {
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			mt, _, _ := mime.ParseMediaType(req.Headers["Content-Type"])
+			if mt != "application/json" || req.Body == nil {
+				return next(req, res)
+			}
+
+			buf := &bytes.Buffer{}
+			err := guardJSON(
+				json.NewDecoder(io.TeeReader(req.Body, buf)),
+				maxDepth,
+				maxKeys,
+			)
+			if err != nil {
+				return err
+			}
+
+			req.Body = io.MultiReader(buf, req.Body)
+
+			return next(req, res)
+		}
+	}
+}
+
+// guardJSON checks the JSON document read from the dec against the maxDepth and
+// the maxKeys.
+func guardJSON(dec *json.Decoder, maxDepth, maxKeys int) error {
+	type frame struct {
+		object bool // Whether the frame is an object
+		key    bool // Whether the next token is a key
+	}
+
+	frames := []*frame{}
+	keys := 0
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			if len(frames) == 0 {
+				return nil
+			}
+			err = io.ErrUnexpectedEOF
+		}
+
+		if err != nil {
+			return &air.Error{
+				Code:    400,
+				Message: err.Error(),
+			}
+		}
+
+		var top *frame
+		if len(frames) > 0 {
+			top = frames[len(frames)-1]
+		}
+
+		if top != nil && top.object && top.key {
+			if _, ok := t.(string); ok {
+				top.key = false
+				keys++
+				if maxKeys > 0 && keys > maxKeys {
+					return &air.Error{
+						Code:    400,
+						Message: "too many JSON keys",
+					}
+				}
+				continue
+			}
+		}
+
+		d, ok := t.(json.Delim)
+		if ok && (d == '}' || d == ']') {
+			frames = frames[:len(frames)-1]
+			continue
+		}
+
+		if top != nil {
+			if top.object {
+				top.key = true
+			} else if keys++; maxKeys > 0 && keys > maxKeys {
+				return &air.Error{
+					Code:    400,
+					Message: "too many JSON elements",
+				}
+			}
+		}
+
+		if ok {
+			frames = append(frames, &frame{
+				object: d == '{',
+				key:    d == '{',
+			})
+			if maxDepth > 0 && len(frames) > maxDepth {
+				return &air.Error{
+					Code:    400,
+					Message: "JSON nested too deeply",
+				}
+			}
+		}
+	}
+}
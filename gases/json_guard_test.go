@@ -0,0 +1,60 @@
+package gases
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONGuard(t *testing.T) {
+	air.POST(
+		"/json_guard",
+		func(req *air.Request, res *air.Response) error {
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			return res.String(string(b))
+		},
+		JSONGuard(3, 5),
+	)
+
+	for _, c := range []struct {
+		body string
+		code int
+	}{
+		{`{"foo":{"bar":[1,2]}}`, 200},
+		{`{"foo":{"bar":[[1]]}}`, 400},
+		{`{"a":1,"b":2,"c":3,"d":4,"e":5,"f":6}`, 400},
+		{`[1,2,3,4,5,6]`, 400},
+		{`{"foo":`, 400},
+	} {
+		req := httptest.NewRequest(
+			"POST",
+			"/json_guard",
+			strings.NewReader(c.body),
+		)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		assert.Equal(t, c.code, rec.Code, c.body)
+		if c.code == 200 {
+			assert.Equal(t, c.body, rec.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(
+		"POST",
+		"/json_guard",
+		strings.NewReader(`[[[[1]]]]`),
+	)
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, `[[[[1]]]]`, rec.Body.String())
+}
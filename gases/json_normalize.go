@@ -0,0 +1,90 @@
+package gases
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sheng/air"
+)
+
+// JSONNormalizeConfig is the configuration of the `JSONNormalize`.
+type JSONNormalizeConfig struct {
+	// ValidateOnly indicates whether the well-formed request bodies are
+	// left as they are instead of being re-encoded in the canonical form
+	// (the object keys sorted and the insignificant whitespace removed).
+	ValidateOnly bool
+}
+
+// JSONNormalize returns a `air.Gas` that reads the JSON (including the "+json")
+// request bodies before the subsequent handlers, rejects the ones that are not
+// well-formed UTF-8 or JSON with the 400 and, unless the
+// `JSONNormalizeConfig.ValidateOnly` is true, re-encodes the rest in the
+// canonical form based on the config. This makes the request bodies suitable
+// for deterministic hashing or signing. The request body remains readable
+// afterward.
+func JSONNormalize(config JSONNormalizeConfig) air.Gas {
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			ct := req.Headers["Content-Type"]
+			mt, _, _ := mime.ParseMediaType(ct)
+			if req.Body == nil || mt != "application/json" &&
+				!strings.HasSuffix(mt, "+json") {
+				return next(req, res)
+			}
+
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+
+			if !utf8.Valid(b) {
+				return &air.Error{
+					Code:    400,
+					Message: "invalid UTF-8 in JSON body",
+				}
+			}
+
+			var v interface{}
+			dec := json.NewDecoder(bytes.NewReader(b))
+			dec.UseNumber()
+			if err := dec.Decode(&v); err != nil {
+				return &air.Error{
+					Code:    400,
+					Message: err.Error(),
+				}
+			} else if _, err := dec.Token(); err != io.EOF {
+				return &air.Error{
+					Code:    400,
+					Message: "trailing data after JSON",
+				}
+			}
+
+			if !config.ValidateOnly {
+				buf := &bytes.Buffer{}
+				enc := json.NewEncoder(buf)
+				enc.SetEscapeHTML(false)
+				if err := enc.Encode(v); err != nil {
+					return err
+				}
+
+				b = bytes.TrimSuffix(buf.Bytes(), []byte{'\n'})
+				req.ContentLength = int64(len(b))
+
+				cl := strconv.Itoa(len(b))
+				if _, ok := req.Headers["Content-Length"]; ok {
+					req.Headers["Content-Length"] = cl
+				}
+			}
+
+			req.Body = bytes.NewReader(b)
+
+			return next(req, res)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package gases
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONNormalize(t *testing.T) {
+	air.POST(
+		"/json_normalize",
+		func(req *air.Request, res *air.Response) error {
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			return res.String(string(b))
+		},
+		JSONNormalize(JSONNormalizeConfig{}),
+	)
+
+	air.POST(
+		"/json_normalize/validate_only",
+		func(req *air.Request, res *air.Response) error {
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			return res.String(string(b))
+		},
+		JSONNormalize(JSONNormalizeConfig{
+			ValidateOnly: true,
+		}),
+	)
+
+	doPath := func(path, contentType, body string) (int, string) {
+		req := httptest.NewRequest(
+			"POST",
+			path,
+			strings.NewReader(body),
+		)
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	do := func(contentType, body string) (int, string) {
+		return doPath("/json_normalize", contentType, body)
+	}
+
+	body := "{\n  \"foo\": \"<bar>\",\n  \"bar\": [1.0, 2, {\"b\": 1, " +
+		"\"a\": 12345678901234567890}]\n}"
+
+	code, b := do("application/json", body)
+	assert.Equal(t, 200, code)
+	assert.Equal(
+		t,
+		`{"bar":[1.0,2,{"a":12345678901234567890,"b":1}],`+
+			`"foo":"<bar>"}`,
+		b,
+	)
+
+	code, b = doPath(
+		"/json_normalize/validate_only",
+		"application/vnd.api+json",
+		body,
+	)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, body, b)
+
+	code, _ = do("application/json", "{\"foo\": \"\xff\"}")
+	assert.Equal(t, 400, code)
+
+	code, _ = do("application/json", "{\"foo\": ")
+	assert.Equal(t, 400, code)
+
+	code, _ = do("application/json", "{} {}")
+	assert.Equal(t, 400, code)
+
+	code, b = do("text/plain", "{\"foo\": \"\xff\"}")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "{\"foo\": \"\xff\"}", b)
+}
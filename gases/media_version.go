@@ -0,0 +1,67 @@
+package gases
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/sheng/air"
+)
+
+// MediaVersion returns a `air.Gas` that resolves the schema version and the
+// format of each request from the vendor media type in its "Content-Type"
+// header (e.g. "application/vnd.base.v2+json" for the version "v2" and the
+// format "json"), and stores them in the `air.Request.Values` with the keys
+// "media_version" and "media_format".
+//
+// The versions are the known versions, and the first of them is the default
+// version for the requests with non-vendor (e.g. "application/json") or
+// unversioned vendor (e.g. "application/vnd.base+json") media types. They are
+// treated as the "v1" only when they are empty. The requests with unknown
+// versions or the media types of other vendors are rejected with the 415.
+func MediaVersion(base string, versions ...string) air.Gas {
+	prefix := "application/vnd." + base
+
+	if len(versions) == 0 {
+		versions = []string{"v1"}
+	}
+
+	defaultVersion := versions[0]
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			ct := req.Headers["Content-Type"]
+			mt, _, _ := mime.ParseMediaType(ct)
+
+			v, f := defaultVersion, ""
+			if i := strings.Index(mt, "/"); i >= 0 {
+				f = mt[i+1:]
+			}
+
+			if strings.HasPrefix(f, "vnd.") {
+				f = ""
+				if i := strings.LastIndex(mt, "+"); i >= 0 {
+					mt, f = mt[:i], mt[i+1:]
+				}
+
+				if strings.HasPrefix(mt, prefix+".") {
+					v = mt[len(prefix)+1:]
+				} else if mt != prefix {
+					v = ""
+				}
+			}
+
+			for _, kv := range versions {
+				if v == kv {
+					req.Values["media_version"] = v
+					req.Values["media_format"] = f
+					return next(req, res)
+				}
+			}
+
+			return &air.Error{
+				Code:    415,
+				Message: "Unsupported Media Type",
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaVersion(t *testing.T) {
+	air.POST(
+		"/media_version",
+		func(req *air.Request, res *air.Response) error {
+			return res.String(req.GetString("media_version") + " " +
+				req.GetString("media_format"))
+		},
+		MediaVersion("myapp", "v1", "v2"),
+	)
+
+	air.POST(
+		"/media_version/default",
+		func(req *air.Request, res *air.Response) error {
+			return res.String(req.GetString("media_version") + " " +
+				req.GetString("media_format"))
+		},
+		MediaVersion("myapp"),
+	)
+
+	doPath := func(path, contentType string) (int, string) {
+		req := httptest.NewRequest(
+			"POST",
+			path,
+			strings.NewReader("{}"),
+		)
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	do := func(contentType string) (int, string) {
+		return doPath("/media_version", contentType)
+	}
+
+	code, body := do("application/vnd.myapp.v2+json; charset=utf-8")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "v2 json", body)
+
+	code, body = do("application/vnd.myapp+xml")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "v1 xml", body)
+
+	code, body = do("application/json")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "v1 json", body)
+
+	code, _ = do("application/vnd.myapp.v3+json")
+	assert.Equal(t, 415, code)
+
+	code, _ = do("application/vnd.other.v1+json")
+	assert.Equal(t, 415, code)
+
+	code, body = doPath("/media_version/default", "application/json")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "v1 json", body)
+
+	code, body = doPath(
+		"/media_version/default",
+		"application/vnd.myapp.v1+json",
+	)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "v1 json", body)
+
+	code, _ = doPath(
+		"/media_version/default",
+		"application/vnd.myapp.v2+json",
+	)
+	assert.Equal(t, 415, code)
+}
@@ -0,0 +1,76 @@
+package gases
+
+import (
+	"mime"
+	"strconv"
+
+	"github.com/sheng/air"
+)
+
+// MinifyConfig is the configuration of the `Minify`.
+type MinifyConfig struct {
+	// Types is the MIME types of the responses to be minified. The types
+	// not supported by the `air.Minify()` pass through. The "text/html",
+	// "text/css", "application/json", "application/xml" and "text/xml" are
+	// minified when it is empty.
+	Types []string
+}
+
+// Minify returns a `air.Gas` that buffers the responses of the subsequent
+// handlers and minifies the ones whose content types are in the
+// `MinifyConfig.Types` by using the `air.Minify()` based on the config, which
+// works regardless of the `air.MinifierEnabled`. The "Content-Length" header
+// of a minified response is adjusted accordingly. The other responses,
+// including the already encoded (e.g. gzipped) ones, pass through untouched.
+func Minify(config MinifyConfig) air.Gas {
+	types := config.Types
+	if len(types) == 0 {
+		types = []string{
+			"text/html",
+			"text/css",
+			"application/json",
+			"application/xml",
+			"text/xml",
+		}
+	}
+
+	minifiable := make(map[string]bool, len(types))
+	for _, t := range types {
+		minifiable[t] = true
+	}
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			rw := res.HTTPResponseWriter()
+			rr := newResponseRecorder(rw)
+			res.SetHTTPResponseWriter(rr)
+			err := next(req, res)
+			res.SetHTTPResponseWriter(rw)
+
+			if !res.Written {
+				return err
+			}
+
+			b := rr.body.Bytes()
+			ct := rr.header.Get("Content-Type")
+			ce := rr.header.Get("Content-Encoding")
+			mt, _, _ := mime.ParseMediaType(ct)
+			if len(b) > 0 && (ce == "" || ce == "identity") &&
+				minifiable[mt] {
+				if mb, merr := air.Minify(mt, b); merr == nil {
+					b = mb
+					rr.header.Set(
+						"Content-Length",
+						strconv.Itoa(len(b)),
+					)
+				}
+			}
+
+			if ferr := rr.flush(rw, b); ferr != nil {
+				return ferr
+			}
+
+			return err
+		}
+	}
+}
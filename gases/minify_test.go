@@ -0,0 +1,79 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinify(t *testing.T) {
+	g := Minify(MinifyConfig{})
+
+	air.GET(
+		"/minify/html",
+		func(req *air.Request, res *air.Response) error {
+			return res.HTML("<html>\n  <body>\n" +
+				"    <p>foo   bar</p>\n  </body>\n</html>\n")
+		},
+		g,
+	)
+
+	png := []byte("\x89PNG\r\n\x1a\n    foobar    ")
+	air.GET(
+		"/minify/image",
+		func(req *air.Request, res *air.Response) error {
+			return res.Blob("image/png", png)
+		},
+		g,
+	)
+
+	air.GET(
+		"/minify/gzip",
+		func(req *air.Request, res *air.Response) error {
+			res.Headers["Content-Encoding"] = "gzip"
+			b := []byte("<p>  foobar  </p>")
+			return res.Blob("text/html", b)
+		},
+		g,
+	)
+
+	air.GET(
+		"/minify/push",
+		func(req *air.Request, res *air.Response) error {
+			res.Flush()
+			return res.HTML(`<img src="/foobar.png">`)
+		},
+		g,
+	)
+
+	req := httptest.NewRequest("GET", "/minify/html", nil)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "<p>foo bar", rec.Body.String())
+	assert.Equal(t, "10", rec.Header().Get("Content-Length"))
+
+	req = httptest.NewRequest("GET", "/minify/image", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, png, rec.Body.Bytes())
+
+	req = httptest.NewRequest("GET", "/minify/gzip", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "<p>  foobar  </p>", rec.Body.String())
+
+	air.AutoPushEnabled = true
+	req = httptest.NewRequest("GET", "/minify/push", nil)
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2", 2, 0
+	rec = httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		air.ServeHTTP(rec, req)
+	})
+	air.AutoPushEnabled = false
+	assert.Equal(t, 200, rec.Code)
+}
@@ -0,0 +1,143 @@
+package gases
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sheng/air"
+)
+
+// mirrorClient is the `http.Client` used by the `Mirror`.
+var mirrorClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+// MirrorConfig is the configuration of the `Mirror`.
+type MirrorConfig struct {
+	// Target is the target that the requests are mirrored to (e.g.
+	// "http://shadow.example.com").
+	Target string
+
+	// QueueSize is the maximum number of the mirrored requests waiting to
+	// be sent. It is treated as 100 when it is less than 1.
+	QueueSize int
+
+	// Workers is the number of the mirrored requests sent concurrently. It
+	// is treated as 1 when it is less than 1.
+	Workers int
+
+	// MaxBodyBytes is the maximum number of bytes of a request body that
+	// is buffered for mirroring. The requests with larger bodies are not
+	// mirrored. It is treated as 1 MiB when it is less than 1.
+	MaxBodyBytes int64
+}
+
+// Mirror returns a `air.Gas` that replays a copy of each request (the method,
+// the end-to-end headers and the buffered body) to the `MirrorConfig.Target`
+// asynchronously and discards its response. The requests are dropped instead
+// of mirrored when the queue is full or their bodies are larger than the
+// `MirrorConfig.MaxBodyBytes`. The failures of the mirrored requests never
+// affect the client.
+func Mirror(config MirrorConfig) air.Gas {
+	target := strings.TrimSuffix(config.Target, "/")
+
+	if config.QueueSize < 1 {
+		config.QueueSize = 100
+	}
+
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+
+	if config.MaxBodyBytes < 1 {
+		config.MaxBodyBytes = 1 << 20
+	}
+
+	queue := make(chan *http.Request, config.QueueSize)
+	for i := 0; i < config.Workers; i++ {
+		go func() {
+			for mreq := range queue {
+				mres, err := mirrorClient.Do(mreq)
+				if err != nil {
+					air.WARN(err)
+					continue
+				}
+				ioutil.ReadAll(mres.Body)
+				mres.Body.Close()
+			}
+		}()
+	}
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			if len(queue) == cap(queue) {
+				air.WARN("mirror queue full, request dropped")
+				return next(req, res)
+			}
+
+			if req.ContentLength > config.MaxBodyBytes {
+				return next(req, res)
+			}
+
+			var b []byte
+			if req.Body != nil {
+				var err error
+				b, err = ioutil.ReadAll(io.LimitReader(
+					req.Body,
+					config.MaxBodyBytes+1,
+				))
+				req.Body = io.MultiReader(
+					bytes.NewReader(b),
+					req.Body,
+				)
+				if err != nil {
+					air.WARN(err)
+					return next(req, res)
+				} else if int64(len(b)) > config.MaxBodyBytes {
+					return next(req, res)
+				}
+			}
+
+			uri := req.URL.Path
+			if req.URL.Query != "" {
+				uri += "?" + req.URL.Query
+			}
+
+			mreq, err := http.NewRequest(
+				req.Method,
+				target+uri,
+				bytes.NewReader(b),
+			)
+			if err != nil {
+				air.ERROR(err)
+				return next(req, res)
+			}
+
+			hr := &air.Request{
+				Headers: map[string]string{},
+			}
+			for k, v := range req.Headers {
+				hr.Headers[k] = v
+			}
+
+			hr.StripHopByHop()
+			for k, v := range hr.Headers {
+				mreq.Header.Set(k, v)
+			}
+
+			mreq.Host = req.URL.Host
+
+			select {
+			case queue <- mreq:
+			default:
+				air.WARN("mirror queue full, request dropped")
+			}
+
+			return next(req, res)
+		}
+	}
+}
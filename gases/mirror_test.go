@@ -0,0 +1,198 @@
+package gases
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirror(t *testing.T) {
+	mirrored := make(chan *http.Request, 1)
+	mirroredBody := make(chan string, 1)
+	ms := httptest.NewServer(http.HandlerFunc(
+		func(rw http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			mirrored <- r
+			mirroredBody <- string(b)
+			rw.WriteHeader(500)
+		},
+	))
+	defer ms.Close()
+
+	air.POST(
+		"/mirror",
+		func(req *air.Request, res *air.Response) error {
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			return res.String("primary: " + string(b))
+		},
+		Mirror(MirrorConfig{
+			Target: ms.URL,
+		}),
+	)
+
+	air.POST(
+		"/mirror/form",
+		func(req *air.Request, res *air.Response) error {
+			return res.String("primary: " + req.Params["foo"])
+		},
+		Mirror(MirrorConfig{
+			Target: ms.URL,
+		}),
+	)
+
+	req := httptest.NewRequest(
+		"POST",
+		"/mirror?foo=bar",
+		strings.NewReader("foobar"),
+	)
+	req.Header.Set("X-Foo", "bar")
+	req.Header.Set("Connection", "X-Bar")
+	req.Header.Set("X-Bar", "foo")
+	req.Header.Set("Keep-Alive", "timeout=5")
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "primary: foobar", rec.Body.String())
+
+	select {
+	case r := <-mirrored:
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/mirror?foo=bar", r.RequestURI)
+		assert.Equal(t, "bar", r.Header.Get("X-Foo"))
+		assert.Empty(t, r.Header.Get("X-Bar"))
+		assert.Empty(t, r.Header.Get("Keep-Alive"))
+		assert.Equal(t, "example.com", r.Host)
+		assert.Equal(t, "foobar", <-mirroredBody)
+	case <-time.After(time.Second):
+		t.Error("request not mirrored")
+	}
+
+	req = httptest.NewRequest(
+		"POST",
+		"/mirror/form",
+		strings.NewReader("foo=bar"),
+	)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "primary: bar", rec.Body.String())
+
+	select {
+	case r := <-mirrored:
+		assert.Equal(t, "/mirror/form", r.RequestURI)
+		assert.Equal(t, "foo=bar", <-mirroredBody)
+	case <-time.After(time.Second):
+		t.Error("request not mirrored")
+	}
+
+	air.POST(
+		"/mirror/large",
+		func(req *air.Request, res *air.Response) error {
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			return res.String("primary: " + string(b))
+		},
+		Mirror(MirrorConfig{
+			Target:       ms.URL,
+			MaxBodyBytes: 3,
+		}),
+	)
+
+	for _, cl := range []bool{true, false} {
+		req = httptest.NewRequest(
+			"POST",
+			"/mirror/large",
+			strings.NewReader("foobar"),
+		)
+		if !cl {
+			req.ContentLength = -1
+		}
+		rec = httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		assert.Equal(t, 200, rec.Code)
+		assert.Equal(t, "primary: foobar", rec.Body.String())
+	}
+
+	select {
+	case <-mirrored:
+		t.Error("request with large body mirrored")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	air.GET(
+		"/mirror/unreachable",
+		func(req *air.Request, res *air.Response) error {
+			return res.String("foobar")
+		},
+		Mirror(MirrorConfig{
+			Target: "http://127.0.0.1:1",
+		}),
+	)
+
+	req = httptest.NewRequest("GET", "/mirror/unreachable", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "foobar", rec.Body.String())
+
+	received := make(chan struct{}, 3)
+	release := make(chan struct{})
+	bs := httptest.NewServer(http.HandlerFunc(
+		func(rw http.ResponseWriter, r *http.Request) {
+			received <- struct{}{}
+			<-release
+		},
+	))
+	defer bs.Close()
+
+	air.GET(
+		"/mirror/busy",
+		func(req *air.Request, res *air.Response) error {
+			return res.String("foobar")
+		},
+		Mirror(MirrorConfig{
+			Target:    bs.URL,
+			QueueSize: 1,
+			Workers:   1,
+		}),
+	)
+
+	do := func() {
+		req := httptest.NewRequest("GET", "/mirror/busy", nil)
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		assert.Equal(t, 200, rec.Code)
+	}
+
+	// The first one is being sent, the second one is queued and the third
+	// one is dropped.
+	do()
+	<-received
+	do()
+	do()
+	close(release)
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Error("queued request not mirrored")
+	}
+
+	select {
+	case <-received:
+		t.Error("request not dropped")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
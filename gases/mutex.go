@@ -0,0 +1,91 @@
+package gases
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sheng/air"
+)
+
+// ResourceLockConfig is the configuration of the `ResourceLock`.
+type ResourceLockConfig struct {
+	// Key returns the key of the resource that the request accesses.
+	Key func(*air.Request) string
+
+	// Timeout is the maximum duration to wait for acquiring the lock of a
+	// resource. It is treated as 5 seconds when it is zero, and the wait is
+	// not limited when it is less than zero.
+	Timeout time.Duration
+}
+
+// ResourceLock returns a `air.Gas` that holds an in-process lock of the
+// resource returned by the `ResourceLockConfig.Key` during the subsequent
+// handlers based on the config, so that the concurrent requests to the same
+// resource are serialized while the ones to different resources run
+// concurrently. The requests failed to acquire the lock within the
+// `ResourceLockConfig.Timeout` are rejected with the 423.
+func ResourceLock(config ResourceLockConfig) air.Gas {
+	if config.Key == nil {
+		panic("gases: resource lock key function can't be nil")
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	mutex := &sync.Mutex{}
+	locks := map[string]*resourceLock{}
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			key := config.Key(req)
+
+			mutex.Lock()
+			l, ok := locks[key]
+			if !ok {
+				l = &resourceLock{
+					ch: make(chan struct{}, 1),
+				}
+				locks[key] = l
+			}
+
+			l.refs++
+			mutex.Unlock()
+
+			defer func() {
+				mutex.Lock()
+				if l.refs--; l.refs == 0 {
+					delete(locks, key)
+				}
+				mutex.Unlock()
+			}()
+
+			var timeout <-chan time.Time
+			if config.Timeout > 0 {
+				t := time.NewTimer(config.Timeout)
+				defer t.Stop()
+				timeout = t.C
+			}
+
+			select {
+			case l.ch <- struct{}{}:
+				defer func() {
+					<-l.ch
+				}()
+			case <-timeout:
+				return &air.Error{
+					Code:    423,
+					Message: "Locked",
+				}
+			}
+
+			return next(req, res)
+		}
+	}
+}
+
+// resourceLock is a lock of a resource of the `ResourceLock`.
+type resourceLock struct {
+	ch   chan struct{}
+	refs int
+}
@@ -0,0 +1,130 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceLock(t *testing.T) {
+	var (
+		inflight = map[string]*int32{
+			"foo": new(int32),
+			"bar": new(int32),
+		}
+		maxInflight = map[string]*int32{
+			"foo": new(int32),
+			"bar": new(int32),
+		}
+		entered = make(chan string, 3)
+		release = make(chan struct{})
+	)
+
+	air.PUT(
+		"/mutex/:id",
+		func(req *air.Request, res *air.Response) error {
+			id := req.Params["id"]
+			n := atomic.AddInt32(inflight[id], 1)
+			if n > atomic.LoadInt32(maxInflight[id]) {
+				atomic.StoreInt32(maxInflight[id], n)
+			}
+
+			entered <- id
+			<-release
+			atomic.AddInt32(inflight[id], -1)
+
+			return res.String(id)
+		},
+		ResourceLock(ResourceLockConfig{
+			Key: func(req *air.Request) string {
+				return req.Params["id"]
+			},
+		}),
+	)
+
+	put := func(path string) int {
+		req := httptest.NewRequest("PUT", path, nil)
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	wg := &sync.WaitGroup{}
+	for _, id := range []string{"foo", "foo", "bar"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			assert.Equal(t, 200, put("/mutex/"+id))
+		}(id)
+	}
+
+	ids := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case id := <-entered:
+			ids[id] = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("the handlers were not entered concurrently")
+		}
+	}
+
+	assert.Equal(t, map[string]bool{"foo": true, "bar": true}, ids)
+
+	select {
+	case <-entered:
+		t.Error("the requests for the same key were not serialized")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, "foo", <-entered)
+	assert.Equal(t, int32(1), atomic.LoadInt32(maxInflight["foo"]))
+	assert.Equal(t, int32(1), atomic.LoadInt32(maxInflight["bar"]))
+
+	locked := make(chan struct{})
+	unlock := make(chan struct{})
+
+	air.PUT(
+		"/mutex/timeout",
+		func(req *air.Request, res *air.Response) error {
+			if req.Headers["X-Hold"] != "" {
+				close(locked)
+				<-unlock
+			}
+			return res.String("foobar")
+		},
+		ResourceLock(ResourceLockConfig{
+			Key: func(req *air.Request) string {
+				return "timeout"
+			},
+			Timeout: 20 * time.Millisecond,
+		}),
+	)
+
+	done := make(chan int)
+	go func() {
+		req := httptest.NewRequest("PUT", "/mutex/timeout", nil)
+		req.Header.Set("X-Hold", "1")
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		done <- rec.Code
+	}()
+
+	<-locked
+	assert.Equal(t, 423, put("/mutex/timeout"))
+
+	close(unlock)
+	assert.Equal(t, 200, <-done)
+	assert.Equal(t, 200, put("/mutex/timeout"))
+
+	assert.Panics(t, func() {
+		ResourceLock(ResourceLockConfig{})
+	})
+}
@@ -0,0 +1,42 @@
+package gases
+
+import "github.com/sheng/air"
+
+// PerRouteLimit returns a `air.Gas` that limits the number of the inflight
+// requests of each route separately, so that a flood on one route cannot starve
+// the others. The limits maps the route patterns (see
+// `air.Request#RoutePattern()`) to their maximum numbers of the inflight
+// requests, and the routes absent from it are not limited. The over-limit
+// requests of a route are rejected with the 503.
+func PerRouteLimit(limits map[string]int) air.Gas {
+	sems := make(map[string]chan struct{}, len(limits))
+	for p, l := range limits {
+		if l < 0 {
+			l = 0
+		}
+		sems[p] = make(chan struct{}, l)
+	}
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			sem, ok := sems[req.RoutePattern()]
+			if !ok {
+				return next(req, res)
+			}
+
+			select {
+			case sem <- struct{}{}:
+				defer func() {
+					<-sem
+				}()
+			default:
+				return &air.Error{
+					Code:    503,
+					Message: "Service Unavailable",
+				}
+			}
+
+			return next(req, res)
+		}
+	}
+}
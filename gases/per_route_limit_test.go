@@ -0,0 +1,71 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerRouteLimit(t *testing.T) {
+	g := PerRouteLimit(map[string]int{
+		"/per_route_limit/slow/:id": 1,
+		"/per_route_limit/fast":     1,
+	})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	air.GET(
+		"/per_route_limit/slow/:id",
+		func(req *air.Request, res *air.Response) error {
+			if req.Params["id"] == "1" {
+				close(started)
+				<-release
+			}
+			return res.String("foobar")
+		},
+		g,
+	)
+
+	air.GET(
+		"/per_route_limit/fast",
+		func(req *air.Request, res *air.Response) error {
+			return res.String("foobar")
+		},
+		g,
+	)
+
+	done := make(chan int)
+	go func() {
+		req := httptest.NewRequest(
+			"GET",
+			"/per_route_limit/slow/1",
+			nil,
+		)
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		done <- rec.Code
+	}()
+
+	<-started
+
+	req := httptest.NewRequest("GET", "/per_route_limit/slow/2", nil)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 503, rec.Code)
+
+	req = httptest.NewRequest("GET", "/per_route_limit/fast", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	close(release)
+	assert.Equal(t, 200, <-done)
+
+	req = httptest.NewRequest("GET", "/per_route_limit/slow/2", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+}
@@ -0,0 +1,103 @@
+package gases
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+
+	"github.com/sheng/air"
+)
+
+// BindQuery returns a `air.Gas` that decodes the query params of each request
+// into a copy of the prototype (a struct or a pointer to a struct) and stores
+// the pointer to the populated copy in the `air.Request.Values` with the key
+// "query". The query params are converted in the same way as the
+// `air.Request#Bind()` converts the params (see `air.BindParam()`), and the
+// requests whose query params cannot be converted into the field types are
+// rejected with the 400.
+//
+// The query param name of a field is specified by its "air" tag and defaults to
+// the field name. A field tagged `air:"-"` is skipped. The default value of a
+// field used when the query param is absent is specified by its "default" tag.
+//
+// It panics when the prototype has a field of an unsupported type.
+func BindQuery(prototype interface{}) air.Gas {
+	pv := reflect.ValueOf(prototype)
+	if pv.Kind() == reflect.Ptr {
+		pv = pv.Elem()
+	}
+
+	if pv.Kind() != reflect.Struct {
+		panic("gases: the prototype must be a struct or a pointer to a " +
+			"struct")
+	}
+
+	pt := pv.Type()
+	for i := 0; i < pt.NumField(); i++ {
+		sf := pt.Field(i)
+		if sf.PkgPath != "" || sf.Tag.Get("air") == "-" {
+			continue
+		}
+
+		fv := reflect.New(sf.Type).Elem()
+		if air.BindParam(fv, "") != nil {
+			panic("gases: unsupported type of the prototype " +
+				"field " + sf.Name)
+		}
+	}
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			q, _ := url.ParseQuery(req.URL.Query)
+
+			v := reflect.New(pt)
+			v.Elem().Set(pv)
+			if err := bindQuery(v.Elem(), q); err != nil {
+				return &air.Error{
+					Code:    400,
+					Message: err.Error(),
+				}
+			}
+
+			req.Values["query"] = v.Interface()
+
+			return next(req, res)
+		}
+	}
+}
+
+// bindQuery binds the q into the struct v.
+func bindQuery(v reflect.Value, q url.Values) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+
+		name := sf.Tag.Get("air")
+		if name == "-" {
+			continue
+		} else if name == "" {
+			name = sf.Name
+		}
+
+		p, ok := "", false
+		if vs := q[name]; len(vs) > 0 {
+			p, ok = vs[0], true
+		} else {
+			p, ok = sf.Tag.Lookup("default")
+		}
+
+		if !ok {
+			continue
+		}
+
+		if air.BindParam(fv, p) != nil {
+			return fmt.Errorf("invalid query param %s: %q", name, p)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+type queryBindTestQuery struct {
+	Name  string `air:"name"`
+	Page  int    `air:"page" default:"1"`
+	Debug bool   `air:"debug"`
+	Skip  string `air:"-"`
+}
+
+func TestBindQuery(t *testing.T) {
+	var q *queryBindTestQuery
+
+	air.GET(
+		"/query_bind",
+		func(req *air.Request, res *air.Response) error {
+			q = req.Values["query"].(*queryBindTestQuery)
+			return res.String("foobar")
+		},
+		BindQuery(queryBindTestQuery{
+			Name: "anonymous",
+			Skip: "foobar",
+		}),
+	)
+
+	req := httptest.NewRequest(
+		"GET",
+		"/query_bind?name=foo&page=2&debug=true&Skip=bar",
+		nil,
+	)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, &queryBindTestQuery{
+		Name:  "foo",
+		Page:  2,
+		Debug: true,
+		Skip:  "foobar",
+	}, q)
+
+	req = httptest.NewRequest("GET", "/query_bind", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, &queryBindTestQuery{
+		Name: "anonymous",
+		Page: 1,
+		Skip: "foobar",
+	}, q)
+
+	req = httptest.NewRequest("GET", "/query_bind?page=foo", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 400, rec.Code)
+	assert.Equal(t, `invalid query param page: "foo"`, rec.Body.String())
+
+	assert.Panics(t, func() {
+		BindQuery(struct {
+			Tags []string `air:"tags"`
+		}{})
+	})
+}
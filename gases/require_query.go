@@ -0,0 +1,48 @@
+package gases
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/sheng/air"
+)
+
+// RequireQueryConfig is the configuration of the `RequireQuery`.
+type RequireQueryConfig struct {
+	// Names is the names of the required query params.
+	Names []string
+
+	// EmptyValueRejected indicates whether a query param with an empty
+	// value counts as missing.
+	EmptyValueRejected bool
+}
+
+// RequireQuery returns a `air.Gas` that rejects the requests missing any of the
+// `RequireQueryConfig.Names` query params with the 400. The message of the
+// rejection names the missing ones.
+func RequireQuery(config RequireQueryConfig) air.Gas {
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			q, _ := url.ParseQuery(req.URL.Query)
+
+			missing := []string{}
+			for _, n := range config.Names {
+				vs, ok := q[n]
+				if !ok || (config.EmptyValueRejected &&
+					(len(vs) == 0 || vs[0] == "")) {
+					missing = append(missing, n)
+				}
+			}
+
+			if len(missing) > 0 {
+				return &air.Error{
+					Code: 400,
+					Message: "missing query params: " +
+						strings.Join(missing, ", "),
+				}
+			}
+
+			return next(req, res)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireQuery(t *testing.T) {
+	air.GET(
+		"/require_query",
+		func(req *air.Request, res *air.Response) error {
+			return res.String("foobar")
+		},
+		RequireQuery(RequireQueryConfig{
+			Names: []string{"foo", "bar"},
+		}),
+	)
+
+	air.GET(
+		"/require_query/non_empty",
+		func(req *air.Request, res *air.Response) error {
+			return res.String("foobar")
+		},
+		RequireQuery(RequireQueryConfig{
+			Names:              []string{"foo", "bar"},
+			EmptyValueRejected: true,
+		}),
+	)
+
+	req := httptest.NewRequest("GET", "/require_query?foo=1&bar=2", nil)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "foobar", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/require_query?foo=1", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 400, rec.Code)
+	assert.Equal(t, "missing query params: bar", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/require_query", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 400, rec.Code)
+	assert.Equal(t, "missing query params: foo, bar", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/require_query?foo=1&bar=", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	req = httptest.NewRequest(
+		"GET",
+		"/require_query/non_empty?foo=1&bar=",
+		nil,
+	)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 400, rec.Code)
+	assert.Equal(t, "missing query params: bar", rec.Body.String())
+}
@@ -0,0 +1,258 @@
+package gases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"reflect"
+
+	"github.com/sheng/air"
+)
+
+// ResponseValidateConfig is the configuration of the `ResponseValidate`.
+type ResponseValidateConfig struct {
+	// Schema is the JSON Schema that the responses are validated against.
+	Schema string
+
+	// Failing indicates whether the mismatched responses are failed with
+	// the 500 instead of only being logged.
+	Failing bool
+}
+
+// ResponseValidate returns a `air.Gas` that validates the "application/json"
+// responses of the subsequent handlers against the
+// `ResponseValidateConfig.Schema` to catch the API contract drift based on the
+// config. It panics when the schema is not valid JSON.
+//
+// It only takes effect in the `air.DebugMode`, so the responses are never
+// altered in production. A mismatch is logged, or, if the
+// `ResponseValidateConfig.Failing` is true, the response is replaced by the
+// 500.
+//
+// Only a subset of the JSON Schema is supported: the "type", "enum",
+// "properties", "required", "additionalProperties", "items", "minimum",
+// "maximum", "minLength", "maxLength", "minItems" and "maxItems" keywords.
+func ResponseValidate(config ResponseValidateConfig) air.Gas {
+	var s interface{}
+	if err := json.Unmarshal([]byte(config.Schema), &s); err != nil {
+		panic("gases: invalid response schema: " + err.Error())
+	}
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			if !air.DebugMode {
+				return next(req, res)
+			}
+
+			rw := res.HTTPResponseWriter()
+			rr := newResponseRecorder(rw)
+			res.SetHTTPResponseWriter(rr)
+			err := next(req, res)
+			res.SetHTTPResponseWriter(rw)
+
+			if !res.Written {
+				return err
+			}
+
+			b := rr.body.Bytes()
+			ct := rr.header.Get("Content-Type")
+			mt, _, _ := mime.ParseMediaType(ct)
+			if mt != "application/json" {
+				return flushAndReturn(rr, rw, b, err)
+			}
+
+			var v interface{}
+			verr := json.Unmarshal(b, &v)
+			if verr == nil {
+				verr = validateJSONSchema(s, v, "$")
+			}
+
+			if verr != nil && config.Failing {
+				res.StatusCode = 500
+				res.Size = 0
+				res.Written = false
+				return &air.Error{
+					Code: 500,
+					Message: "response does not match " +
+						"the schema: " + verr.Error(),
+				}
+			} else if verr != nil {
+				air.ERROR(
+					"response does not match the schema",
+					map[string]interface{}{
+						"path":  req.URL.Path,
+						"error": verr.Error(),
+					},
+				)
+			}
+
+			return flushAndReturn(rr, rw, b, err)
+		}
+	}
+}
+
+// flushAndReturn flushes the rr to the rw with the b as the body and returns
+// the err, or the error occurred while flushing.
+func flushAndReturn(
+	rr *responseRecorder,
+	rw http.ResponseWriter,
+	b []byte,
+	err error,
+) error {
+	if ferr := rr.flush(rw, b); ferr != nil {
+		return ferr
+	}
+
+	return err
+}
+
+// validateJSONSchema validates the v at the path against the schema.
+func validateJSONSchema(schema, v interface{}, path string) error {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if t, ok := s["type"]; ok {
+		ts, ok := t.([]interface{})
+		if !ok {
+			ts = []interface{}{t}
+		}
+
+		matched := false
+		for _, t := range ts {
+			if tn, _ := t.(string); jsonSchemaTypeOf(tn, v) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return fmt.Errorf("%s: must be of type %v", path, t)
+		}
+	}
+
+	if e, ok := s["enum"].([]interface{}); ok {
+		matched := false
+		for _, ev := range e {
+			if reflect.DeepEqual(ev, v) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return fmt.Errorf("%s: must be one of %v", path, e)
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		ps, _ := s["properties"].(map[string]interface{})
+		if r, ok := s["required"].([]interface{}); ok {
+			for _, n := range r {
+				n, _ := n.(string)
+				if _, ok := v[n]; n != "" && !ok {
+					return fmt.Errorf(
+						"%s: missing property %q",
+						path,
+						n,
+					)
+				}
+			}
+		}
+
+		for n, pv := range v {
+			if ps, ok := ps[n]; ok {
+				err := validateJSONSchema(ps, pv, path+"."+n)
+				if err != nil {
+					return err
+				}
+			} else if s["additionalProperties"] == false {
+				return fmt.Errorf(
+					"%s: unexpected property %q",
+					path,
+					n,
+				)
+			}
+		}
+	case []interface{}:
+		if err := validateJSONSchemaLimits(
+			s,
+			"Items",
+			float64(len(v)),
+			path,
+		); err != nil {
+			return err
+		}
+
+		for i, iv := range v {
+			if err := validateJSONSchema(
+				s["items"],
+				iv,
+				fmt.Sprintf("%s[%d]", path, i),
+			); err != nil {
+				return err
+			}
+		}
+	case string:
+		return validateJSONSchemaLimits(
+			s,
+			"Length",
+			float64(len([]rune(v))),
+			path,
+		)
+	case float64:
+		if min, ok := s["minimum"].(float64); ok && v < min {
+			return fmt.Errorf("%s: must be >= %v", path, min)
+		}
+
+		if max, ok := s["maximum"].(float64); ok && v > max {
+			return fmt.Errorf("%s: must be <= %v", path, max)
+		}
+	}
+
+	return nil
+}
+
+// validateJSONSchemaLimits validates the n at the path against the "min" and
+// "max" keywords with the suffix in the schema s.
+func validateJSONSchemaLimits(
+	s map[string]interface{},
+	suffix string,
+	n float64,
+	path string,
+) error {
+	if min, ok := s["min"+suffix].(float64); ok && n < min {
+		return errors.New(path + ": too short")
+	}
+
+	if max, ok := s["max"+suffix].(float64); ok && n > max {
+		return errors.New(path + ": too long")
+	}
+
+	return nil
+}
+
+// jsonSchemaTypeOf reports whether the v is of the JSON Schema type t.
+func jsonSchemaTypeOf(t string, v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case float64:
+		return t == "number" || t == "integer" && v == math.Trunc(v)
+	case string:
+		return t == "string"
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	}
+
+	return false
+}
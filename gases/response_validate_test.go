@@ -0,0 +1,106 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseValidate(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["id", "name"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"name": {"type": "string", "minLength": 1},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"status": {"enum": ["active", "inactive"]}
+		}
+	}`
+
+	body := map[string]interface{}{}
+	air.GET(
+		"/response_validate",
+		func(req *air.Request, res *air.Response) error {
+			return res.JSON(body)
+		},
+		ResponseValidate(ResponseValidateConfig{
+			Schema:  schema,
+			Failing: true,
+		}),
+	)
+
+	air.GET(
+		"/response_validate/logged",
+		func(req *air.Request, res *air.Response) error {
+			return res.JSON(body)
+		},
+		ResponseValidate(ResponseValidateConfig{
+			Schema: schema,
+		}),
+	)
+
+	getPath := func(path string) (int, string) {
+		req := httptest.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	get := func() (int, string) {
+		return getPath("/response_validate")
+	}
+
+	air.DebugMode = true
+	defer func() {
+		air.DebugMode = false
+	}()
+
+	body = map[string]interface{}{
+		"id":     1,
+		"name":   "foo",
+		"tags":   []string{"bar"},
+		"status": "active",
+	}
+
+	code, b := get()
+	assert.Equal(t, 200, code)
+	assert.JSONEq(
+		t,
+		`{"id":1,"name":"foo","status":"active","tags":["bar"]}`,
+		b,
+	)
+
+	for _, bad := range []map[string]interface{}{
+		{"id": 1},
+		{"id": 1.5, "name": "foo"},
+		{"id": 0, "name": "foo"},
+		{"id": 1, "name": ""},
+		{"id": 1, "name": "foo", "tags": []int{1}},
+		{"id": 1, "name": "foo", "status": "deleted"},
+		{"id": 1, "name": "foo", "extra": true},
+	} {
+		body = bad
+		code, b = get()
+		assert.Equal(t, 500, code)
+		assert.Contains(t, b, "response does not match the schema")
+	}
+
+	code, b = getPath("/response_validate/logged")
+	assert.Equal(t, 200, code)
+	assert.JSONEq(t, `{"extra":true,"id":1,"name":"foo"}`, b)
+
+	air.DebugMode = false
+
+	code, _ = get()
+	assert.Equal(t, 200, code)
+
+	assert.Panics(t, func() {
+		ResponseValidate(ResponseValidateConfig{
+			Schema: "{",
+		})
+	})
+}
@@ -0,0 +1,159 @@
+package gases
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sheng/air"
+)
+
+// SequenceConfig is the configuration of the `Sequence`.
+type SequenceConfig struct {
+	// Store is the store of the last-seen sequence numbers.
+	Store SequenceStore
+
+	// Header is the name of the header that the sequence numbers of the
+	// requests are read from. It is treated as the "X-Sequence-Number" when
+	// it is empty.
+	Header string
+
+	// Key returns the key of the session that the request belongs to. The
+	// host of the remote address of the request is used when it is nil.
+	Key func(*air.Request) string
+
+	// GapsRejected indicates whether a sequence number that skips some of
+	// the numbers after the last-seen one is rejected. When it is true,
+	// only the number right after the last-seen one is accepted.
+	GapsRejected bool
+}
+
+// SequenceStore is the store of the last-seen sequence numbers used by the
+// `Sequence`.
+type SequenceStore interface {
+	// Advance atomically sets the last-seen sequence number of the key to
+	// the n if the valid reports true for the current one, and reports
+	// whether it did. The current one is 0 for an unseen or expired key.
+	Advance(key string, n uint64, valid func(last uint64) bool) bool
+}
+
+// Sequence returns a `air.Gas` that enforces the ordering of the requests of
+// each session by the monotonically increasing sequence numbers (starting from
+// 1) read from the `SequenceConfig.Header` based on the config. The requests
+// without a valid sequence number are rejected with the 400, and the
+// out-of-order or replayed ones are rejected with the 409.
+func Sequence(config SequenceConfig) air.Gas {
+	if config.Header == "" {
+		config.Header = "X-Sequence-Number"
+	}
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			n, err := strconv.ParseUint(
+				req.Headers[config.Header],
+				10,
+				64,
+			)
+			if err != nil || n == 0 {
+				return &air.Error{
+					Code:    400,
+					Message: "Bad Request",
+				}
+			}
+
+			key := ""
+			if config.Key != nil {
+				key = config.Key(req)
+			} else if h, _, err := net.SplitHostPort(
+				req.RemoteAddr,
+			); err == nil {
+				key = h
+			} else {
+				key = req.RemoteAddr
+			}
+
+			valid := func(last uint64) bool {
+				if config.GapsRejected {
+					return n == last+1
+				}
+				return n > last
+			}
+
+			if !config.Store.Advance(key, n, valid) {
+				return &air.Error{
+					Code:    409,
+					Message: "Conflict",
+				}
+			}
+
+			return next(req, res)
+		}
+	}
+}
+
+// NewMemorySequenceStore returns a new in-memory `SequenceStore` that forgets
+// the last-seen sequence number of a key when it has not been advanced for the
+// ttl. The numbers never expire when the ttl is less than or equal to zero.
+// The expired numbers are evicted at most once per the ttl.
+func NewMemorySequenceStore(ttl time.Duration) SequenceStore {
+	return &memorySequenceStore{
+		ttl:     ttl,
+		entries: map[string]*memorySequenceEntry{},
+		sweptAt: time.Now(),
+	}
+}
+
+// memorySequenceStore is an in-memory `SequenceStore`.
+type memorySequenceStore struct {
+	sync.Mutex
+
+	ttl     time.Duration
+	entries map[string]*memorySequenceEntry
+	sweptAt time.Time
+}
+
+// memorySequenceEntry is an entry of the `memorySequenceStore`.
+type memorySequenceEntry struct {
+	last      uint64
+	expiresAt time.Time
+}
+
+// Advance implements the `SequenceStore`.
+func (mss *memorySequenceStore) Advance(
+	key string,
+	n uint64,
+	valid func(last uint64) bool,
+) bool {
+	now := time.Now()
+
+	mss.Lock()
+	defer mss.Unlock()
+
+	if mss.ttl > 0 && now.Sub(mss.sweptAt) >= mss.ttl {
+		for k, e := range mss.entries {
+			if !now.Before(e.expiresAt) {
+				delete(mss.entries, k)
+			}
+		}
+
+		mss.sweptAt = now
+	}
+
+	last := uint64(0)
+	if e, ok := mss.entries[key]; ok &&
+		(mss.ttl <= 0 || now.Before(e.expiresAt)) {
+		last = e.last
+	}
+
+	if !valid(last) {
+		return false
+	}
+
+	mss.entries[key] = &memorySequenceEntry{
+		last:      n,
+		expiresAt: now.Add(mss.ttl),
+	}
+
+	return true
+}
@@ -0,0 +1,94 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequence(t *testing.T) {
+	air.POST(
+		"/sequence",
+		func(req *air.Request, res *air.Response) error {
+			return res.String("foobar")
+		},
+		Sequence(SequenceConfig{
+			Store: NewMemorySequenceStore(time.Minute),
+		}),
+	)
+
+	air.POST(
+		"/sequence/strict",
+		func(req *air.Request, res *air.Response) error {
+			return res.String("foobar")
+		},
+		Sequence(SequenceConfig{
+			Store:        NewMemorySequenceStore(time.Minute),
+			Header:       "X-Seq",
+			GapsRejected: true,
+		}),
+	)
+
+	do := func(session string, n int) int {
+		req := httptest.NewRequest("POST", "/sequence", nil)
+		req.RemoteAddr = session + ":1234"
+		if n > 0 {
+			req.Header.Set("X-Sequence-Number", strconv.Itoa(n))
+		}
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, 200, do("192.0.2.1", 1))
+	assert.Equal(t, 200, do("192.0.2.1", 2))
+	assert.Equal(t, 200, do("192.0.2.1", 3))
+	assert.Equal(t, 200, do("192.0.2.2", 1))
+
+	assert.Equal(t, 409, do("192.0.2.1", 3))
+	assert.Equal(t, 409, do("192.0.2.1", 2))
+	assert.Equal(t, 400, do("192.0.2.1", 0))
+
+	assert.Equal(t, 200, do("192.0.2.1", 5))
+
+	doStrict := func(n int) int {
+		req := httptest.NewRequest("POST", "/sequence/strict", nil)
+		req.Header.Set("X-Seq", strconv.Itoa(n))
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, 200, doStrict(1))
+	assert.Equal(t, 409, doStrict(3))
+	assert.Equal(t, 200, doStrict(2))
+}
+
+func TestMemorySequenceStore(t *testing.T) {
+	s := NewMemorySequenceStore(10 * time.Millisecond)
+	valid := func(n uint64) func(uint64) bool {
+		return func(last uint64) bool {
+			return n > last
+		}
+	}
+
+	assert.True(t, s.Advance("foo", 1, valid(1)))
+	assert.False(t, s.Advance("foo", 1, valid(1)))
+
+	time.Sleep(20 * time.Millisecond)
+
+	assert.True(t, s.Advance("foo", 1, valid(1)))
+	assert.Len(t, s.(*memorySequenceStore).entries, 1)
+
+	assert.True(t, s.Advance("bar", 1, valid(1)))
+	assert.Len(t, s.(*memorySequenceStore).entries, 2)
+
+	time.Sleep(20 * time.Millisecond)
+
+	assert.True(t, s.Advance("bar", 1, valid(1)))
+	assert.Len(t, s.(*memorySequenceStore).entries, 1)
+}
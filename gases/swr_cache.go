@@ -0,0 +1,357 @@
+package gases
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sheng/air"
+)
+
+// SWRConfig is the configuration of the `StaleWhileRevalidate`.
+type SWRConfig struct {
+	// MaxAge is the duration a cached response stays fresh.
+	MaxAge time.Duration
+
+	// StaleWindow is the duration after the `MaxAge` that a stale cached
+	// response can still be served while it is being revalidated.
+	StaleWindow time.Duration
+
+	// MaxEntries is the maximum number of the cached responses. The expired
+	// ones, and then the oldest ones, are evicted to make room for the new
+	// ones. It is treated as 1000 when it is less than 1.
+	MaxEntries int
+}
+
+// StaleWhileRevalidate returns a `air.Gas` that caches the 200 responses of the
+// GET requests by their URLs based on the config.
+//
+// A fresh cached response is served directly. A stale cached response within
+// the `SWRConfig.StaleWindow` is also served immediately, but a copy of the
+// request without the conditional headers is dispatched through the whole
+// handler chain in the background to revalidate it. The other requests are
+// handled by the subsequent handlers as usual. Only the headers and the
+// cookies set by the subsequent handlers are cached, and the ones set by the
+// previous gases are applied to each response.
+//
+// The requests with the "Cookie" or the "Authorization" header are never
+// served from or stored in the cache, and neither are the responses with the
+// "Set-Cookie" header, the "Cache-Control: private" or "no-store" or the
+// "Vary: *". A cached response is only served to the requests whose headers
+// named by its "Vary" header match the ones of the request it was stored for.
+func StaleWhileRevalidate(config SWRConfig) air.Gas {
+	if config.MaxEntries < 1 {
+		config.MaxEntries = 1000
+	}
+
+	mutex := &sync.Mutex{}
+	entries := map[string]*swrEntry{}
+
+	// store stores the e with the key. It must be called with the mutex
+	// locked.
+	store := func(key string, e *swrEntry) {
+		if _, ok := entries[key]; !ok &&
+			len(entries) >= config.MaxEntries {
+			ttl := config.MaxAge + config.StaleWindow
+
+			oldestKey, oldest := "", (*swrEntry)(nil)
+			for k, oe := range entries {
+				if time.Since(oe.storedAt) >= ttl {
+					delete(entries, k)
+				} else if oldest == nil ||
+					oe.storedAt.Before(oldest.storedAt) {
+					oldestKey, oldest = k, oe
+				}
+			}
+
+			if len(entries) >= config.MaxEntries {
+				delete(entries, oldestKey)
+			}
+		}
+
+		entries[key] = e
+	}
+
+	token, err := randomHex(16)
+	if err != nil {
+		panic("gases: " + err.Error())
+	}
+
+	return func(next air.Handler) air.Handler {
+		// fetch runs the next with the req and the res, and returns the
+		// response to be cached, or nil when it is not cacheable. Only
+		// the headers and the cookies set by the next are cached.
+		fetch := func(
+			req *air.Request,
+			res *air.Response,
+		) (*swrEntry, error) {
+			headers, cookies := res.Headers, res.Cookies
+			res.Headers, res.Cookies = map[string]string{}, nil
+
+			rw := res.HTTPResponseWriter()
+			rr := newResponseRecorder(rw)
+			res.SetHTTPResponseWriter(rr)
+			err := next(req, res)
+			res.SetHTTPResponseWriter(rw)
+
+			for k, v := range res.Headers {
+				headers[k] = v
+			}
+
+			res.Headers = headers
+			res.Cookies = append(cookies, res.Cookies...)
+
+			if !res.Written {
+				return nil, err
+			}
+
+			h := http.Header{}
+			for k, v := range rr.header {
+				h[k] = v
+			}
+
+			swrMergeHeader(rr.header, headers, cookies)
+			if ferr := rr.flush(rw, rr.body.Bytes()); ferr != nil {
+				return nil, ferr
+			}
+
+			if err != nil || rr.statusCode != 200 ||
+				!swrCacheable(h) {
+				return nil, err
+			}
+
+			e := &swrEntry{
+				header:   h,
+				body:     rr.body.Bytes(),
+				storedAt: time.Now(),
+				vary:     map[string]string{},
+			}
+
+			for _, v := range h["Vary"] {
+				for _, n := range strings.Split(v, ",") {
+					n = http.CanonicalHeaderKey(
+						strings.TrimSpace(n),
+					)
+					if n != "" {
+						e.vary[n] = req.Headers[n]
+					}
+				}
+			}
+
+			return e, nil
+		}
+
+		// revalidate dispatches the hr to revalidate the e cached with
+		// the uri.
+		revalidate := func(uri string, e *swrEntry, hr *http.Request) {
+			air.ServeHTTP(newResponseRecorder(nil), hr)
+
+			mutex.Lock()
+			if entries[uri] == e {
+				e.revalidating = false
+			}
+			mutex.Unlock()
+		}
+
+		return func(req *air.Request, res *air.Response) error {
+			if req.Method != "GET" || req.Headers["Cookie"] != "" ||
+				req.Headers["Authorization"] != "" {
+				return next(req, res)
+			}
+
+			uri := req.URL.Path
+			if req.URL.Query != "" {
+				uri += "?" + req.URL.Query
+			}
+
+			if req.Headers[swrRevalidateHeader] == token {
+				delete(req.Headers, swrRevalidateHeader)
+
+				ne, err := fetch(req, res)
+				if ne != nil {
+					mutex.Lock()
+					store(uri, ne)
+					mutex.Unlock()
+				}
+
+				return err
+			}
+
+			mutex.Lock()
+			e := entries[uri]
+			if e != nil && !e.matches(req) {
+				e = nil
+			}
+
+			age := time.Duration(0)
+			if e != nil {
+				age = time.Since(e.storedAt)
+			}
+
+			switch {
+			case e != nil && age < config.MaxAge:
+			case e != nil && age < config.MaxAge+config.StaleWindow:
+				if !e.revalidating {
+					hr, err := swrRevalidateRequest(
+						req,
+						uri,
+						token,
+					)
+					if err == nil {
+						e.revalidating = true
+						go revalidate(uri, e, hr)
+					}
+				}
+			default:
+				mutex.Unlock()
+
+				ne, err := fetch(req, res)
+				if ne != nil {
+					mutex.Lock()
+					store(uri, ne)
+					mutex.Unlock()
+				}
+
+				return err
+			}
+			mutex.Unlock()
+
+			return e.serve(res, age)
+		}
+	}
+}
+
+// swrRevalidateHeader is the name of the header that marks the requests
+// dispatched by the `StaleWhileRevalidate` to revalidate its cached responses.
+const swrRevalidateHeader = "X-Air-Swr-Revalidate"
+
+// swrRevalidateRequest returns a new `http.Request` that revalidates the cached
+// response of the req to the uri. It carries the token in the
+// `swrRevalidateHeader` and none of the conditional headers of the req.
+func swrRevalidateRequest(
+	req *air.Request,
+	uri string,
+	token string,
+) (*http.Request, error) {
+	hr, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	hr.Host = req.URL.Host
+	hr.RemoteAddr = req.RemoteAddr
+	for k, v := range req.Headers {
+		switch k {
+		case "If-Match", "If-None-Match", "If-Modified-Since",
+			"If-Unmodified-Since", "If-Range":
+		default:
+			hr.Header.Set(k, v)
+		}
+	}
+
+	hr.Header.Set(swrRevalidateHeader, token)
+
+	return hr, nil
+}
+
+// swrEntry is a cached response of the `StaleWhileRevalidate`.
+type swrEntry struct {
+	header       http.Header
+	body         []byte
+	storedAt     time.Time
+	vary         map[string]string
+	revalidating bool
+}
+
+// matches reports whether the se can be served to the req, that is, whether
+// the headers of the req named by the "Vary" header of the se match the ones of
+// the request the se was stored for.
+func (se *swrEntry) matches(req *air.Request) bool {
+	for n, v := range se.vary {
+		if req.Headers[n] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// serve serves the se as the res with the age. The current headers and
+// cookies of the res are applied as well.
+func (se *swrEntry) serve(res *air.Response, age time.Duration) error {
+	rw := res.HTTPResponseWriter()
+	for k, v := range se.header {
+		rw.Header()[k] = v
+	}
+
+	swrMergeHeader(rw.Header(), res.Headers, res.Cookies)
+	rw.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+	rw.WriteHeader(200)
+
+	res.StatusCode = 200
+	res.Written = true
+
+	n, err := rw.Write(se.body)
+	res.Size += int64(n)
+
+	return err
+}
+
+// swrMergeHeader merges the headers that are not already in the h and the
+// cookies into the h.
+func swrMergeHeader(
+	h http.Header,
+	headers map[string]string,
+	cookies []*air.Cookie,
+) {
+	for k, v := range headers {
+		if _, ok := h[http.CanonicalHeaderKey(k)]; !ok {
+			h.Set(k, v)
+		}
+	}
+
+	scs := []string{}
+	for _, c := range cookies {
+		if v := c.String(); v != "" {
+			scs = append(scs, v)
+		}
+	}
+
+	if len(scs) > 0 {
+		h["Set-Cookie"] = append(scs, h["Set-Cookie"]...)
+	}
+}
+
+// swrCacheable reports whether the response with the h can be cached by the
+// `StaleWhileRevalidate`.
+func swrCacheable(h http.Header) bool {
+	if len(h["Set-Cookie"]) > 0 {
+		return false
+	}
+
+	for _, v := range h["Cache-Control"] {
+		for _, d := range strings.Split(v, ",") {
+			d = strings.TrimSpace(d)
+			if i := strings.Index(d, "="); i >= 0 {
+				d = d[:i]
+			}
+
+			switch strings.ToLower(strings.TrimSpace(d)) {
+			case "private", "no-store":
+				return false
+			}
+		}
+	}
+
+	for _, v := range h["Vary"] {
+		for _, n := range strings.Split(v, ",") {
+			if strings.TrimSpace(n) == "*" {
+				return false
+			}
+		}
+	}
+
+	return true
+}
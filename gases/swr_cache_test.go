@@ -0,0 +1,232 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	g := StaleWhileRevalidate(SWRConfig{
+		MaxAge:      100 * time.Millisecond,
+		StaleWindow: time.Minute,
+	})
+
+	n := int32(0)
+	air.GET(
+		"/swr_cache",
+		func(req *air.Request, res *air.Response) error {
+			return res.String(strconv.Itoa(
+				int(atomic.AddInt32(&n, 1)),
+			))
+		},
+		g,
+	)
+
+	m := int32(0)
+	air.GET(
+		"/swr_cache/no_store",
+		func(req *air.Request, res *air.Response) error {
+			res.Headers["Cache-Control"] = "no-store"
+			return res.String(strconv.Itoa(
+				int(atomic.AddInt32(&m, 1)),
+			))
+		},
+		g,
+	)
+
+	counter := func(
+		c *int32,
+		header map[string]string,
+	) func(*air.Request, *air.Response) error {
+		return func(req *air.Request, res *air.Response) error {
+			for k, v := range header {
+				res.Headers[k] = v
+			}
+			return res.String(strconv.Itoa(
+				int(atomic.AddInt32(c, 1)),
+			))
+		}
+	}
+
+	p := int32(0)
+	air.GET("/swr_cache/private", counter(&p, map[string]string{
+		"Cache-Control": "max-age=60, private",
+	}), g)
+
+	sc := int32(0)
+	air.GET("/swr_cache/set_cookie", counter(&sc, map[string]string{
+		"Set-Cookie": "foo=bar",
+	}), g)
+
+	v := int32(0)
+	air.GET("/swr_cache/vary", counter(&v, map[string]string{
+		"Vary": "Accept-Language",
+	}), g)
+
+	c := int32(0)
+	air.GET("/swr_cache/cookie", counter(&c, nil), g)
+
+	getWithHeader := func(path string, header map[string]string) string {
+		req := httptest.NewRequest("GET", path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		assert.Equal(t, 200, rec.Code)
+		return rec.Body.String()
+	}
+
+	get := func(path string) string {
+		return getWithHeader(path, nil)
+	}
+
+	assert.Equal(t, "1", get("/swr_cache"))
+	assert.Equal(t, "1", get("/swr_cache"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&n))
+
+	time.Sleep(150 * time.Millisecond)
+
+	assert.Equal(t, "1", get("/swr_cache"))
+
+	b := ""
+	for i := 0; i < 100 && b != "2"; i++ {
+		time.Sleep(10 * time.Millisecond)
+		b = get("/swr_cache")
+	}
+
+	assert.Equal(t, "2", b)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&n))
+
+	assert.Equal(t, "1", get("/swr_cache/no_store"))
+	assert.Equal(t, "2", get("/swr_cache/no_store"))
+
+	assert.Equal(t, "1", get("/swr_cache/private"))
+	assert.Equal(t, "2", get("/swr_cache/private"))
+
+	assert.Equal(t, "1", get("/swr_cache/set_cookie"))
+	assert.Equal(t, "2", get("/swr_cache/set_cookie"))
+
+	en := map[string]string{"Accept-Language": "en"}
+	fr := map[string]string{"Accept-Language": "fr"}
+	assert.Equal(t, "1", getWithHeader("/swr_cache/vary", en))
+	assert.Equal(t, "1", getWithHeader("/swr_cache/vary", en))
+	assert.Equal(t, "2", getWithHeader("/swr_cache/vary", fr))
+	assert.Equal(t, "2", getWithHeader("/swr_cache/vary", fr))
+	assert.Equal(t, "3", getWithHeader("/swr_cache/vary", en))
+
+	cookie := map[string]string{"Cookie": "foo=bar"}
+	auth := map[string]string{"Authorization": "Bearer foobar"}
+	assert.Equal(t, "1", get("/swr_cache/cookie"))
+	assert.Equal(t, "2", getWithHeader("/swr_cache/cookie", cookie))
+	assert.Equal(t, "3", getWithHeader("/swr_cache/cookie", auth))
+	assert.Equal(t, "1", get("/swr_cache/cookie"))
+}
+
+func TestStaleWhileRevalidateMaxEntries(t *testing.T) {
+	n := int32(0)
+	air.GET(
+		"/swr_cache_max_entries",
+		func(req *air.Request, res *air.Response) error {
+			return res.String(strconv.Itoa(
+				int(atomic.AddInt32(&n, 1)),
+			))
+		},
+		StaleWhileRevalidate(SWRConfig{
+			MaxAge:     time.Minute,
+			MaxEntries: 2,
+		}),
+	)
+
+	get := func(query string) string {
+		req := httptest.NewRequest(
+			"GET",
+			"/swr_cache_max_entries?"+query,
+			nil,
+		)
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		assert.Equal(t, 200, rec.Code)
+		return rec.Body.String()
+	}
+
+	assert.Equal(t, "1", get("a"))
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, "2", get("b"))
+	assert.Equal(t, "1", get("a"))
+	assert.Equal(t, "3", get("c"))
+	assert.Equal(t, "2", get("b"))
+	assert.Equal(t, "3", get("c"))
+	assert.Equal(t, "4", get("a"))
+}
+
+func TestStaleWhileRevalidateOuterGases(t *testing.T) {
+	id := int32(0)
+	requestID := func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			n := strconv.Itoa(int(atomic.AddInt32(&id, 1)))
+			res.Headers["X-Request-Id"] = n
+			res.Cookies = append(res.Cookies, &air.Cookie{
+				Name:  "request_id",
+				Value: n,
+			})
+			return next(req, res)
+		}
+	}
+
+	n := int32(0)
+	air.GET(
+		"/swr_cache_outer_gases",
+		func(req *air.Request, res *air.Response) error {
+			res.Headers["ETag"] = `"foobar"`
+			return res.String(strconv.Itoa(
+				int(atomic.AddInt32(&n, 1)),
+			))
+		},
+		requestID,
+		StaleWhileRevalidate(SWRConfig{
+			MaxAge:      100 * time.Millisecond,
+			StaleWindow: time.Minute,
+		}),
+	)
+
+	get := func(inm string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/swr_cache_outer_gases", nil)
+		if inm != "" {
+			req.Header.Set("If-None-Match", inm)
+		}
+		rec := httptest.NewRecorder()
+		air.ServeHTTP(rec, req)
+		assert.Equal(t, 200, rec.Code)
+		return rec
+	}
+
+	rec := get("")
+	assert.Equal(t, "1", rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get("X-Request-Id"))
+	assert.Equal(t, []string{"request_id=1"}, rec.Header()["Set-Cookie"])
+
+	rec = get("")
+	assert.Equal(t, "1", rec.Body.String())
+	assert.Equal(t, "2", rec.Header().Get("X-Request-Id"))
+	assert.Equal(t, []string{"request_id=2"}, rec.Header()["Set-Cookie"])
+	assert.Equal(t, `"foobar"`, rec.Header().Get("ETag"))
+
+	time.Sleep(150 * time.Millisecond)
+
+	assert.Equal(t, "1", get(`"foobar"`).Body.String())
+
+	b := ""
+	for i := 0; i < 100 && b != "2"; i++ {
+		time.Sleep(10 * time.Millisecond)
+		b = get("").Body.String()
+	}
+
+	assert.Equal(t, "2", b)
+}
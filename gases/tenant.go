@@ -0,0 +1,45 @@
+package gases
+
+import (
+	"net/http"
+
+	"github.com/sheng/air"
+)
+
+// TenantConfig is the configuration of the `Tenant`.
+type TenantConfig struct {
+	// Resolver resolves the tenant identifier of the request.
+	Resolver func(*air.Request) (string, error)
+
+	// FailureCode is the status code the requests whose tenant cannot be
+	// resolved are rejected with. It should be the 400 or the 404, and it
+	// is treated as the 400 when it is zero.
+	FailureCode int
+}
+
+// Tenant returns a `air.Gas` that resolves the tenant identifier of each
+// request by using the `TenantConfig.Resolver` and stores it in the
+// `air.Request.Values` with the key "tenant". A failed resolution (an error or
+// an empty identifier) is rejected with the `TenantConfig.FailureCode`.
+func Tenant(config TenantConfig) air.Gas {
+	if config.FailureCode == 0 {
+		config.FailureCode = 400
+	}
+
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			t, err := config.Resolver(req)
+			if err != nil || t == "" {
+				c := config.FailureCode
+				return &air.Error{
+					Code:    c,
+					Message: http.StatusText(c),
+				}
+			}
+
+			req.Values["tenant"] = t
+
+			return next(req, res)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package gases
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenant(t *testing.T) {
+	h := func(req *air.Request, res *air.Response) error {
+		return res.String(req.GetString("tenant"))
+	}
+
+	air.GET(
+		"/tenant/subdomain",
+		h,
+		Tenant(TenantConfig{
+			Resolver: func(req *air.Request) (string, error) {
+				host := hostname(req.URL.Host)
+				i := strings.Index(host, ".")
+				if i > 0 && strings.HasSuffix(
+					host,
+					".example.com",
+				) {
+					return host[:i], nil
+				}
+				return "", errors.New("unknown tenant")
+			},
+		}),
+	)
+
+	air.GET(
+		"/tenant/header",
+		h,
+		Tenant(TenantConfig{
+			Resolver: func(req *air.Request) (string, error) {
+				return req.Headers["X-Tenant-Id"], nil
+			},
+			FailureCode: 404,
+		}),
+	)
+
+	req := httptest.NewRequest(
+		"GET",
+		"http://foo.example.com/tenant/subdomain",
+		nil,
+	)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "foo", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/tenant/header", nil)
+	req.Header.Set("X-Tenant-ID", "bar")
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "bar", rec.Body.String())
+
+	req = httptest.NewRequest(
+		"GET",
+		"http://example.org/tenant/subdomain",
+		nil,
+	)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 400, rec.Code)
+
+	req = httptest.NewRequest("GET", "/tenant/header", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 404, rec.Code)
+	assert.Equal(t, "Not Found", rec.Body.String())
+}
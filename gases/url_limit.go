@@ -0,0 +1,30 @@
+package gases
+
+import "github.com/sheng/air"
+
+// URLLimit returns a `air.Gas` that rejects the requests whose request URI (the
+// path and the query) is longer than the maxBytes with the 414. The requests
+// skipped by any of the optional skippers pass through.
+func URLLimit(maxBytes int, skippers ...Skipper) air.Gas {
+	return func(next air.Handler) air.Handler {
+		return func(req *air.Request, res *air.Response) error {
+			if skipped(skippers, req) {
+				return next(req, res)
+			}
+
+			l := len(req.URL.Path)
+			if req.URL.Query != "" {
+				l += 1 + len(req.URL.Query)
+			}
+
+			if l > maxBytes {
+				return &air.Error{
+					Code:    414,
+					Message: "URI Too Long",
+				}
+			}
+
+			return next(req, res)
+		}
+	}
+}
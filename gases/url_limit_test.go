@@ -0,0 +1,46 @@
+package gases
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sheng/air"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLLimit(t *testing.T) {
+	h := func(req *air.Request, res *air.Response) error {
+		return res.String("foobar")
+	}
+
+	air.GET("/url_limit", h, URLLimit(27))
+	air.GET("/url_limit/skipped", h, URLLimit(27, func(*air.Request) bool {
+		return true
+	}))
+
+	req := httptest.NewRequest("GET", "/url_limit?foo=bar", nil)
+	rec := httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	req = httptest.NewRequest("GET", "/url_limit?foo=bar&bar=foo1", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	req = httptest.NewRequest("GET", "/url_limit?foo=bar&bar=foo12", nil)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 414, rec.Code)
+	assert.Equal(t, "URI Too Long", rec.Body.String())
+
+	req = httptest.NewRequest(
+		"GET",
+		"/url_limit/skipped?foo="+strings.Repeat("a", 64),
+		nil,
+	)
+	rec = httptest.NewRecorder()
+	air.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+}
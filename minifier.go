@@ -29,18 +29,25 @@ var theMinifier = &minifier{
 	once:     &sync.Once{},
 }
 
-// minify minifies the b based on the mimeType.
+// minify minifies the b based on the mimeType if the `MinifierEnabled` is true.
 func (m *minifier) minify(mimeType string, b []byte) ([]byte, error) {
 	if !MinifierEnabled {
 		return b, nil
 	}
 
+	return m.forceMinify(mimeType, b)
+}
+
+// forceMinify minifies the b based on the mimeType regardless of the
+// `MinifierEnabled`.
+func (m *minifier) forceMinify(mimeType string, b []byte) ([]byte, error) {
 	m.once.Do(func() {
 		m.minifier.Add("text/html", html.DefaultMinifier)
 		m.minifier.Add("text/css", css.DefaultMinifier)
 		m.minifier.Add("application/javascript", js.DefaultMinifier)
 		m.minifier.Add("application/json", json.DefaultMinifier)
 		m.minifier.Add("application/xml", xml.DefaultMinifier)
+		m.minifier.Add("text/xml", xml.DefaultMinifier)
 		m.minifier.Add("image/svg+xml", svg.DefaultMinifier)
 		m.minifier.AddFunc("image/jpeg", func(
 			m *minify.M,
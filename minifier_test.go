@@ -121,3 +121,20 @@ func TestMinifier(t *testing.T) {
 	theMinifier.minifier = minify.New()
 	theMinifier.once = &sync.Once{}
 }
+
+func TestMinify(t *testing.T) {
+	b, err := Minify("text/html", []byte("<!DOCTYPE html>"))
+	assert.Equal(t, "<!doctype html>", string(b))
+	assert.NoError(t, err)
+
+	b, err = Minify("text/xml", []byte("<foo> bar </foo>"))
+	assert.Equal(t, "<foo>bar</foo>", string(b))
+	assert.NoError(t, err)
+
+	b, err = Minify("foo/bar", []byte("foobar"))
+	assert.Equal(t, "foobar", string(b))
+	assert.NoError(t, err)
+
+	theMinifier.minifier = minify.New()
+	theMinifier.once = &sync.Once{}
+}
@@ -1,6 +1,28 @@
 package air
 
-import "io"
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 // Request is an HTTP request.
 type Request struct {
@@ -15,9 +37,1226 @@ type Request struct {
 	Files         map[string]io.Reader
 	RemoteAddr    string
 	Values        map[string]interface{}
+
+	httpRequest     *http.Request
+	routePattern    string
+	routeParamNames []string
+	connID          uint64
+	connRequestNum  uint64
+	bodyCounter     *bodyCounter
+	bodyAwaiter     *bodyAwaiter
+	formParsed      bool
+	formError       error
 }
 
 // Bind binds the r into the v.
 func (r *Request) Bind(v interface{}) error {
 	return theBinder.bind(v, r)
 }
+
+// RawHeaders returns the header block of the r serialized in the wire format.
+// Each header value is written as a "Name: value\r\n" line, so the repeated
+// headers (e.g. the "Cookie" or the "Via") result in multiple lines, and the
+// block ends with an empty line.
+//
+// The underlying server canonicalizes the header names and does not retain the
+// order in which the different headers were received, so the lines are sorted
+// by the canonical header names to keep the serialization deterministic. The
+// values of a repeated header keep their received order.
+func (r *Request) RawHeaders() []byte {
+	h := http.Header{}
+	if r.httpRequest != nil {
+		h = r.httpRequest.Header
+	} else {
+		for k, v := range r.Headers {
+			h[k] = []string{v}
+		}
+	}
+
+	ks := make([]string, 0, len(h))
+	for k := range h {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	buf := bytes.Buffer{}
+	for _, k := range ks {
+		for _, v := range h[k] {
+			buf.WriteString(k)
+			buf.WriteString(": ")
+			buf.WriteString(v)
+			buf.WriteString("\r\n")
+		}
+	}
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// IsSafe reports whether the method of the r is safe. The safe methods are
+// "GET", "HEAD", "OPTIONS" and "TRACE".
+func (r *Request) IsSafe() bool {
+	switch r.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE":
+		return true
+	}
+	return false
+}
+
+// IsIdempotent reports whether the method of the r is idempotent. The
+// idempotent methods are the safe methods (see `IsSafe()`), "PUT" and "DELETE".
+func (r *Request) IsIdempotent() bool {
+	return r.IsSafe() || r.Method == "PUT" || r.Method == "DELETE"
+}
+
+// QueryParamArray returns the values of the query param named the name in the
+// r. The repeated form ("?id=1&id=2"), the bracket form ("?id[]=1&id[]=2") and,
+// when the `QueryParamArrayCSVEnabled` is true, the comma form ("?id=1,2") are
+// all normalized into a single slice.
+func (r *Request) QueryParamArray(name string) []string {
+	if r.URL == nil {
+		return nil
+	}
+
+	q, _ := url.ParseQuery(r.URL.Query)
+	vs := append(q[name], q[name+"[]"]...)
+	if QueryParamArrayCSVEnabled && len(vs) == 1 {
+		vs = strings.Split(vs[0], ",")
+	}
+
+	return vs
+}
+
+// RoutePattern returns the path pattern of the route that matched the r (e.g.
+// "/users/:id") rather than the concrete path. It returns "" when no route
+// matched the r.
+func (r *Request) RoutePattern() string {
+	return r.routePattern
+}
+
+// ContentDisposition parses the "Content-Disposition" header of the r and
+// returns the disposition type, the suggested file name and all the params.
+// The RFC 5987 encoded values (e.g. the "filename*" param) are decoded and take
+// precedence over their plain forms.
+func (r *Request) ContentDisposition() (
+	dispType string,
+	filename string,
+	params map[string]string,
+) {
+	cd := r.Headers["Content-Disposition"]
+	if cd == "" {
+		return "", "", nil
+	}
+
+	dispType, params, err := mime.ParseMediaType(cd)
+	if err != nil {
+		return "", "", nil
+	}
+
+	return dispType, params["filename"], params
+}
+
+// Path returns the URL-decoded path of the r without the query. The path is
+// returned as is when it cannot be decoded.
+func (r *Request) Path() string {
+	if r.URL == nil {
+		return ""
+	}
+
+	p, err := url.PathUnescape(r.URL.Path)
+	if err != nil {
+		return r.URL.Path
+	}
+
+	return p
+}
+
+// RawPath returns the undecoded path of the r without the query.
+//
+// The router matches the routes against the raw path and decodes the route
+// params from it segment by segment, so an encoded "/" ("%2F") inside a route
+// param value does not split the value.
+func (r *Request) RawPath() string {
+	if r.URL == nil {
+		return ""
+	}
+
+	return r.URL.Path
+}
+
+// ConnID returns the identifier of the connection that the r was received on.
+// It is unique among the connections accepted by the server and 0 when the r
+// was not received by the server (e.g. served through the `ServeHTTP()`).
+func (r *Request) ConnID() uint64 {
+	return r.connID
+}
+
+// ConnRequestNum returns the sequence number of the r among the requests
+// received on the same connection, starting from 1. It helps to correlate the
+// keep-alive reuse of the connections. It is 0 when the r was not received by
+// the server.
+func (r *Request) ConnRequestNum() uint64 {
+	return r.connRequestNum
+}
+
+// GetString returns the string value associated with the key in the `Values` of
+// the r. It returns "" when the value is absent or not a string.
+func (r *Request) GetString(key string) string {
+	s, _ := r.Values[key].(string)
+	return s
+}
+
+// GetInt returns the int value associated with the key in the `Values` of the
+// r and reports whether the value is present and an int.
+func (r *Request) GetInt(key string) (int, bool) {
+	i, ok := r.Values[key].(int)
+	return i, ok
+}
+
+// OpenFormFile opens the first uploaded file of the multipart form field named
+// the name in the r and returns it along with its header. It returns the
+// `http.ErrMissingFile` when the field is absent.
+func (r *Request) OpenFormFile(name string) (
+	multipart.File,
+	*multipart.FileHeader,
+	error,
+) {
+	r.ParseForm()
+	if r.httpRequest == nil || r.httpRequest.MultipartForm == nil {
+		return nil, nil, http.ErrMissingFile
+	}
+
+	fhs := r.httpRequest.MultipartForm.File[name]
+	if len(fhs) == 0 {
+		return nil, nil, http.ErrMissingFile
+	}
+
+	f, err := fhs[0].Open()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return f, fhs[0], nil
+}
+
+// FormValueBytes returns the raw bytes of the first value of the form field
+// named the name in the r. The form consists of the URL query and the request
+// body. It is binary-safe and returns nil when the field is absent.
+func (r *Request) FormValueBytes(name string) []byte {
+	v, ok := r.formValue(name)
+	if !ok {
+		return nil
+	}
+
+	return []byte(v)
+}
+
+// FormValueDefault returns the first value of the form field named the name in
+// the r, or the def when the field is absent or empty.
+func (r *Request) FormValueDefault(name, def string) string {
+	if v, _ := r.formValue(name); v != "" {
+		return v
+	}
+
+	return def
+}
+
+// formValue returns the first value of the form field named the name in the r
+// and reports whether the field is present.
+func (r *Request) formValue(name string) (string, bool) {
+	if r.httpRequest == nil {
+		return "", false
+	}
+
+	r.ParseForm()
+
+	vs := r.httpRequest.Form[name]
+	if len(vs) == 0 {
+		return "", false
+	}
+
+	return vs[0], true
+}
+
+// ParseForm parses both the URL query and the body form data of the r and
+// returns the error occurred while parsing them, so that the malformed forms
+// can be rejected deliberately. The form data are parsed only once, and the
+// `Params`, `Files` and the form value methods of the r share the result.
+//
+// The server only puts the URL query into the `Params` of the r before the
+// gases run, and parses the body form data right before the handler of the
+// matched route runs, so that the gases can inspect or wrap the `Body` of the
+// r before it is read. The gases that need the body form data should call the
+// `ParseForm` first. The body form data are read from the `Body` of the r,
+// and they take precedence over the URL query but not over the path params.
+func (r *Request) ParseForm() error {
+	if r.httpRequest == nil || r.formParsed {
+		return r.formError
+	}
+
+	r.formParsed = true
+
+	hr := r.httpRequest
+	if r.Body == nil {
+		hr.Body = http.NoBody
+	} else if rc, ok := r.Body.(io.ReadCloser); ok {
+		hr.Body = rc
+	} else {
+		hr.Body = ioutil.NopCloser(r.Body)
+	}
+
+	err := hr.ParseMultipartForm(32 << 20)
+	if err != nil && err != http.ErrNotMultipart {
+		r.formError = err
+	}
+
+	pns := make(map[string]bool, len(r.routeParamNames))
+	for _, pn := range r.routeParamNames {
+		pns[pn] = true
+	}
+
+	for k, v := range hr.PostForm {
+		if len(v) > 0 && !pns[k] {
+			r.Params[k] = v[0]
+		}
+	}
+
+	if hr.MultipartForm != nil {
+		for k, v := range hr.MultipartForm.File {
+			if len(v) > 0 {
+				if f, err := v[0].Open(); err == nil {
+					r.Files[k] = f
+				}
+			}
+		}
+	}
+
+	return r.formError
+}
+
+// Dump serializes the r into the HTTP/1.x wire representation (the request
+// line, the "Host" header, the headers and, if the includeBody is true, the
+// body). The body of the r is buffered and remains readable afterward.
+func (r *Request) Dump(includeBody bool) ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteString(r.Method)
+	buf.WriteByte(' ')
+	if r.URL != nil {
+		buf.WriteString(r.URL.Path)
+		if r.URL.Query != "" {
+			buf.WriteByte('?')
+			buf.WriteString(r.URL.Query)
+		}
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(r.Proto)
+	buf.WriteString("\r\n")
+	if r.URL != nil && r.URL.Host != "" {
+		buf.WriteString("Host: ")
+		buf.WriteString(r.URL.Host)
+		buf.WriteString("\r\n")
+	}
+	buf.Write(r.RawHeaders())
+	if includeBody && r.Body != nil {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		r.Body = bytes.NewReader(b)
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// BodyReceived reports whether the full body of the r has been read from the
+// client. It is always true when the r was not received by the server.
+func (r *Request) BodyReceived() bool {
+	if r.bodyCounter == nil {
+		return true
+	}
+
+	if r.ContentLength >= 0 &&
+		atomic.LoadInt64(&r.bodyCounter.n) >= r.ContentLength {
+		return true
+	}
+
+	return atomic.LoadInt32(&r.bodyCounter.eof) == 1
+}
+
+// AwaitBody reads the rest of the body of the r from the client into the memory
+// and waits at most the timeout for it to be fully received. It returns an
+// `Error` with the 408 when the timeout is reached, and an `Error` with the 413
+// when more than the maxBytes are read (it is not limited when the maxBytes is
+// less than or equal to zero). In both cases the reading stops, and the body of
+// the r remains readable afterward: reading it yields what has been read into
+// the memory followed by the rest of it.
+func (r *Request) AwaitBody(timeout time.Duration, maxBytes int64) error {
+	if r.bodyAwaiter == nil {
+		if r.BodyReceived() || r.Body == nil {
+			return nil
+		}
+
+		ba := &bodyAwaiter{
+			buffer: &bytes.Buffer{},
+			body:   r.Body,
+			stop:   make(chan struct{}),
+			done:   make(chan struct{}),
+		}
+
+		go ba.await(maxBytes)
+
+		r.Body = ba
+		r.bodyAwaiter = ba
+	}
+
+	ba := r.bodyAwaiter
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case <-ba.done:
+	case <-t.C:
+		ba.stopOnce.Do(func() {
+			close(ba.stop)
+		})
+		return &Error{408, "Request Timeout"}
+	}
+
+	if ba.tooLarge {
+		return &Error{413, "Request Entity Too Large"}
+	} else if ba.stopped {
+		return &Error{408, "Request Timeout"}
+	}
+
+	return ba.err
+}
+
+// BodyReadCloser returns the body of the r as an `io.ReadCloser`, so that it
+// can be passed to the libraries expecting one and closed deterministically.
+// Closing it more than once is safe, and reading it after closing returns an
+// error.
+func (r *Request) BodyReadCloser() io.ReadCloser {
+	var b io.Reader = http.NoBody
+	if r.Body != nil {
+		b = r.Body
+	}
+
+	return &bodyReadCloser{
+		reader: b,
+	}
+}
+
+// Done returns a channel that is closed when the client goes away, so that the
+// handlers doing expensive work can abort by selecting on it. It fires when the
+// connection of the r is closed mid-request (or when the HTTP/2 stream of the r
+// is canceled), and after the r has been served. It returns nil, which is never
+// closed, when the r was not received by the server.
+func (r *Request) Done() <-chan struct{} {
+	if r.httpRequest == nil {
+		return nil
+	}
+
+	return r.httpRequest.Context().Done()
+}
+
+// StripHopByHop removes the hop-by-hop headers, which must not be forwarded by
+// the proxies, from the r. They are the "Connection", "Keep-Alive",
+// "Proxy-Authenticate", "Proxy-Authorization", "Proxy-Connection", "TE",
+// "Trailer", "Trailers", "Transfer-Encoding" and "Upgrade", and any of the
+// headers named in the "Connection".
+func (r *Request) StripHopByHop() {
+	names := append([]string{}, hopByHopHeaders...)
+
+	cs := []string{r.Headers["Connection"]}
+	if r.httpRequest != nil {
+		cs = r.httpRequest.Header["Connection"]
+	}
+
+	for _, c := range cs {
+		for _, n := range strings.Split(c, ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				n = http.CanonicalHeaderKey(n)
+				names = append(names, n)
+			}
+		}
+	}
+
+	for _, n := range names {
+		delete(r.Headers, n)
+		if r.httpRequest != nil {
+			r.httpRequest.Header.Del(n)
+		}
+	}
+}
+
+// hopByHopHeaders is the names of the hop-by-hop headers.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Trailers",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// ExpectsContinue reports whether the client of the r expects the 100 Continue
+// before sending the body (by the "Expect: 100-continue" header).
+func (r *Request) ExpectsContinue() bool {
+	return strings.EqualFold(
+		strings.TrimSpace(r.Headers["Expect"]),
+		"100-continue",
+	)
+}
+
+// MultipartWithProgress wraps the body of the r, so that the fn is invoked with
+// the number of the bytes read so far and the content length of the r (-1 when
+// it is unknown) each time the multipart body is read further. This enables the
+// server-side upload progress tracking.
+//
+// The server parses the body form data of the r right before the handler of
+// the matched route runs (see the `ParseForm`), so the `MultipartWithProgress`
+// should be called in a gas to track the parsing of the multipart form. The fn
+// is invoked at once when any of the body has already been read.
+func (r *Request) MultipartWithProgress(fn func(read, total int64)) {
+	read := int64(0)
+	if r.bodyCounter != nil {
+		read = atomic.LoadInt64(&r.bodyCounter.n)
+	}
+
+	if read > 0 {
+		fn(read, r.ContentLength)
+	}
+
+	if r.Body != nil {
+		r.Body = &progressReader{
+			reader: r.Body,
+			read:   read,
+			total:  r.ContentLength,
+			fn:     fn,
+		}
+	}
+}
+
+// DrainBody reads and discards the rest of the body of the r. It returns an
+// error without draining further when the rest is larger than the maxBytes.
+// The rest is not limited when the maxBytes is less than or equal to zero.
+//
+// It is rarely needed, since the underlying server already discards a small
+// unread body after the r has been served and closes the connection when more
+// is left. It must not be called for the r whose `ExpectsContinue()` is true
+// unless the body is wanted, since reading the body makes the server send the
+// 100 Continue and invites the client to upload it.
+func (r *Request) DrainBody(maxBytes int64) error {
+	if r.Body == nil {
+		return nil
+	}
+
+	if maxBytes <= 0 {
+		_, err := io.Copy(ioutil.Discard, r.Body)
+		return err
+	}
+
+	n, err := io.Copy(
+		ioutil.Discard,
+		io.LimitReader(r.Body, maxBytes+1),
+	)
+	if err != nil {
+		return err
+	} else if n > maxBytes {
+		return errors.New("air: request body too large to drain")
+	}
+
+	return nil
+}
+
+// bodyCounter is an `io.ReadCloser` that counts the bytes read from the request
+// body.
+type bodyCounter struct {
+	io.ReadCloser
+
+	n   int64
+	eof int32
+}
+
+// Read implements the `io.Reader`.
+func (bc *bodyCounter) Read(b []byte) (int, error) {
+	n, err := bc.ReadCloser.Read(b)
+	atomic.AddInt64(&bc.n, int64(n))
+	if err == io.EOF {
+		atomic.StoreInt32(&bc.eof, 1)
+	}
+	return n, err
+}
+
+// progressReader is an `io.Reader` that reports the progress of reading the
+// request body.
+type progressReader struct {
+	reader io.Reader
+	read   int64
+	total  int64
+	fn     func(read, total int64)
+}
+
+// Read implements the `io.Reader`.
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.reader.Read(b)
+	if n > 0 {
+		pr.read += int64(n)
+		pr.fn(pr.read, pr.total)
+	}
+	return n, err
+}
+
+// bodyAwaiter is an `io.Reader` that reads the request body buffered by the
+// `Request#AwaitBody()`.
+type bodyAwaiter struct {
+	buffer   *bytes.Buffer
+	body     io.Reader
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	stopped  bool
+	tooLarge bool
+	eof      bool
+	err      error
+}
+
+// await reads the body of the ba into the buffer of the ba until the end of it
+// is reached, an error occurs, more than the maxBytes are read or the ba is
+// stopped.
+func (ba *bodyAwaiter) await(maxBytes int64) {
+	defer close(ba.done)
+
+	b := make([]byte, 32<<10)
+	for {
+		select {
+		case <-ba.stop:
+			ba.stopped = true
+			return
+		default:
+		}
+
+		n, err := ba.body.Read(b)
+		ba.buffer.Write(b[:n])
+		if err == io.EOF {
+			ba.eof = true
+			return
+		} else if err != nil {
+			ba.err = err
+			return
+		} else if maxBytes > 0 && int64(ba.buffer.Len()) > maxBytes {
+			ba.tooLarge = true
+			return
+		}
+	}
+}
+
+// Read implements the `io.Reader`.
+func (ba *bodyAwaiter) Read(b []byte) (int, error) {
+	<-ba.done
+	if ba.buffer.Len() > 0 {
+		return ba.buffer.Read(b)
+	} else if ba.err != nil {
+		return 0, ba.err
+	} else if ba.eof {
+		return 0, io.EOF
+	}
+
+	return ba.body.Read(b)
+}
+
+// SignedCookie returns the unsigned value of the signed cookie named the name
+// in the r. The value of a signed cookie is in the form "value|signature",
+// where the signature is the hex-encoded HMAC-SHA256 of the value keyed by the
+// secret. It returns an error when the cookie is absent or has been tampered
+// with.
+func (r *Request) SignedCookie(name, secret string) (string, error) {
+	var c *Cookie
+	for _, rc := range r.Cookies {
+		if rc.Name == name {
+			c = rc
+			break
+		}
+	}
+
+	if c == nil {
+		return "", errors.New("air: named cookie not present")
+	}
+
+	i := strings.LastIndexByte(c.Value, '|')
+	if i < 0 {
+		return "", errors.New("air: cookie not signed")
+	}
+
+	sig, err := hex.DecodeString(c.Value[i+1:])
+	if err != nil {
+		return "", errors.New("air: invalid cookie signature")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(c.Value[:i]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", errors.New("air: invalid cookie signature")
+	}
+
+	return c.Value[:i], nil
+}
+
+// CookieJSON decodes the value of the cookie named the name in the r by the
+// `CookieJSONEncoding` and unmarshals it as JSON into the v. It returns an
+// error when the cookie is absent or malformed.
+func (r *Request) CookieJSON(name string, v interface{}) error {
+	var c *Cookie
+	for _, rc := range r.Cookies {
+		if rc.Name == name {
+			c = rc
+			break
+		}
+	}
+
+	if c == nil {
+		return errors.New("air: named cookie not present")
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+
+	switch CookieJSONEncoding {
+	case "url":
+		var s string
+		if s, err = url.QueryUnescape(c.Value); err == nil {
+			b = []byte(s)
+		}
+	case "base64":
+		b, err = base64.RawURLEncoding.DecodeString(
+			strings.TrimRight(c.Value, "="),
+		)
+	default:
+		return errors.New("air: unsupported cookie json encoding")
+	}
+
+	if err != nil {
+		return errors.New("air: malformed cookie value: " + err.Error())
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return errors.New("air: malformed json cookie: " + err.Error())
+	}
+
+	return nil
+}
+
+// Prefer returns the value of the preference named the name in the "Prefer"
+// header (RFC 7240) of the r and reports whether the preference is present. The
+// value of a boolean preference (e.g. "respond-async") is "".
+func (r *Request) Prefer(name string) (value string, ok bool) {
+	for _, p := range strings.Split(r.Headers["Prefer"], ",") {
+		if i := strings.IndexByte(p, ';'); i >= 0 {
+			p = p[:i]
+		}
+
+		n, v := p, ""
+		if i := strings.IndexByte(p, '='); i >= 0 {
+			n, v = p[:i], strings.TrimSpace(p[i+1:])
+			if len(v) > 1 && v[0] == '"' && v[len(v)-1] == '"' {
+				v = v[1 : len(v)-1]
+			}
+		}
+
+		if strings.EqualFold(strings.TrimSpace(n), name) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// StreamBody reads the body of the r in chunks of the chunkSize and calls the
+// fn with each chunk. Only the last chunk may be shorter than the chunkSize. It
+// stops at the first error returned by the fn and returns it. The chunk passed
+// to the fn is only valid until the fn returns.
+func (r *Request) StreamBody(chunkSize int, fn func([]byte) error) error {
+	if r.Body == nil {
+		return nil
+	}
+
+	if chunkSize <= 0 {
+		return errors.New("air: chunk size must be positive")
+	}
+
+	b := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(r.Body, b)
+		if n > 0 {
+			if err := fn(b[:n]); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// bodyReadCloser is the `io.ReadCloser` returned by the
+// `Request#BodyReadCloser()`.
+type bodyReadCloser struct {
+	reader io.Reader
+	closed int32
+}
+
+// Read implements the `io.Reader`.
+func (brc *bodyReadCloser) Read(b []byte) (int, error) {
+	if atomic.LoadInt32(&brc.closed) == 1 {
+		return 0, errors.New("air: read on closed body")
+	}
+	return brc.reader.Read(b)
+}
+
+// Close implements the `io.Closer`.
+func (brc *bodyReadCloser) Close() error {
+	if !atomic.CompareAndSwapInt32(&brc.closed, 0, 1) {
+		return nil
+	}
+	if c, ok := brc.reader.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// UserAgentInfo returns the browser and OS data parsed from the "User-Agent"
+// header of the r. The parsing is done by a lightweight matcher that only knows
+// the common user agents, so the fields it cannot recognize are left empty.
+func (r *Request) UserAgentInfo() UserAgentInfo {
+	ua := r.Headers["User-Agent"]
+	uai := UserAgentInfo{}
+	if ua == "" {
+		return uai
+	}
+
+	lua := strings.ToLower(ua)
+	for _, k := range userAgentBotKeywords {
+		if i := strings.Index(lua, k); i >= 0 {
+			uai.IsBot = true
+
+			s := strings.LastIndexAny(ua[:i], " ;(+") + 1
+			e := strings.IndexAny(ua[i:], " ;)") + i
+			if e < i {
+				e = len(ua)
+			}
+
+			uai.Browser = ua[s:e]
+			if j := strings.Index(uai.Browser, "/"); j >= 0 {
+				uai.BrowserVersion = uai.Browser[j+1:]
+				uai.Browser = uai.Browser[:j]
+			}
+
+			break
+		}
+	}
+
+	if !uai.IsBot {
+		for _, b := range userAgentBrowsers {
+			if strings.Contains(ua, b.token) && (b.requires == "" ||
+				strings.Contains(ua, b.requires)) {
+				uai.Browser = b.name
+				uai.BrowserVersion = userAgentTokenValue(
+					ua,
+					b.versionToken,
+				)
+				break
+			}
+		}
+	}
+
+	for _, o := range userAgentOSes {
+		if strings.Contains(ua, o.token) {
+			uai.OS = o.name
+			break
+		}
+	}
+
+	switch {
+	case uai.IsBot:
+		uai.Device = "Bot"
+	case strings.Contains(ua, "iPad"),
+		strings.Contains(ua, "Android") &&
+			!strings.Contains(ua, "Mobile"):
+		uai.Device = "Tablet"
+	case strings.Contains(ua, "Mobile"), strings.Contains(ua, "iPhone"):
+		uai.Device = "Mobile"
+	default:
+		uai.Device = "Desktop"
+	}
+
+	return uai
+}
+
+// UserAgentInfo is the browser and OS data parsed from a "User-Agent" header.
+type UserAgentInfo struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	Device         string
+	IsBot          bool
+}
+
+// userAgentBotKeywords is the lowercase keywords that identify the crawlers.
+var userAgentBotKeywords = []string{
+	"bot",
+	"crawler",
+	"spider",
+	"slurp",
+	"facebookexternalhit",
+	"mediapartners",
+}
+
+// userAgentBrowsers is the browsers known by the `UserAgentInfo`. The order
+// matters, since most of the browsers also claim to be the ones after them.
+var userAgentBrowsers = []struct {
+	name         string
+	token        string
+	requires     string
+	versionToken string
+}{
+	{"Edge", "Edg/", "", "Edg/"},
+	{"Edge", "Edge/", "", "Edge/"},
+	{"Opera", "OPR/", "", "OPR/"},
+	{"Chrome", "CriOS/", "", "CriOS/"},
+	{"Chrome", "Chrome/", "", "Chrome/"},
+	{"Firefox", "FxiOS/", "", "FxiOS/"},
+	{"Firefox", "Firefox/", "", "Firefox/"},
+	{"Safari", "Safari/", "Version/", "Version/"},
+	{"Internet Explorer", "MSIE ", "", "MSIE "},
+	{"Internet Explorer", "Trident/", "rv:", "rv:"},
+}
+
+// userAgentOSes is the operating systems known by the `UserAgentInfo`.
+var userAgentOSes = []struct {
+	name  string
+	token string
+}{
+	{"Windows", "Windows"},
+	{"iOS", "iPhone"},
+	{"iOS", "iPad"},
+	{"iOS", "iPod"},
+	{"Android", "Android"},
+	{"Chrome OS", "CrOS"},
+	{"macOS", "Mac OS X"},
+	{"Linux", "Linux"},
+}
+
+// userAgentTokenValue returns the value following the token in the ua.
+func userAgentTokenValue(ua, token string) string {
+	i := strings.Index(ua, token)
+	if i < 0 {
+		return ""
+	}
+
+	v := ua[i+len(token):]
+	if j := strings.IndexAny(v, " ;)"); j >= 0 {
+		v = v[:j]
+	}
+
+	return v
+}
+
+// DecodeXML streams the body of the r through an `xml.Decoder` into the v. The
+// r is rejected with the 415 when its content type is not XML, unless the
+// `XMLContentTypeLenient` is true.
+func (r *Request) DecodeXML(v interface{}) error {
+	if !XMLContentTypeLenient {
+		mt, _, _ := mime.ParseMediaType(r.Headers["Content-Type"])
+		if mt != "application/xml" && mt != "text/xml" &&
+			!strings.HasSuffix(mt, "+xml") {
+			return &Error{415, "Unsupported Media Type"}
+		}
+	}
+
+	if r.Body == nil {
+		return &Error{400, "request body can't be empty"}
+	}
+
+	if err := xml.NewDecoder(r.Body).Decode(v); err != nil {
+		return &Error{400, "malformed xml: " + err.Error()}
+	}
+
+	return nil
+}
+
+// ClientHints returns the User-Agent Client Hints parsed from the "Sec-CH-UA"
+// family headers of the r. The absent hints are left as the zero values.
+func (r *Request) ClientHints() ClientHints {
+	h := func(name string) string {
+		return sfString(r.Headers["Sec-Ch-Ua"+name])
+	}
+
+	ch := ClientHints{
+		Platform:        h("-Platform"),
+		PlatformVersion: h("-Platform-Version"),
+		Mobile:          h("-Mobile") == "?1",
+		Model:           h("-Model"),
+		Arch:            h("-Arch"),
+		Bitness:         h("-Bitness"),
+		FullVersion:     h("-Full-Version"),
+	}
+
+	for _, item := range sfSplit(r.Headers["Sec-Ch-Ua"], ',') {
+		ps := sfSplit(item, ';')
+		if len(ps) == 0 || ps[0] == "" {
+			continue
+		}
+
+		b := ClientHintsBrand{
+			Brand: sfString(ps[0]),
+		}
+		for _, p := range ps[1:] {
+			if strings.HasPrefix(p, "v=") {
+				b.Version = sfString(p[2:])
+			}
+		}
+
+		ch.Brands = append(ch.Brands, b)
+	}
+
+	return ch
+}
+
+// ClientHints is the User-Agent Client Hints of a request.
+type ClientHints struct {
+	Brands          []ClientHintsBrand
+	Platform        string
+	PlatformVersion string
+	Mobile          bool
+	Model           string
+	Arch            string
+	Bitness         string
+	FullVersion     string
+}
+
+// ClientHintsBrand is a brand listed in the "Sec-CH-UA" header.
+type ClientHintsBrand struct {
+	Brand   string
+	Version string
+}
+
+// sfSplit splits the structured field s by the sep outside the quoted strings
+// and trims the spaces around each part.
+func sfSplit(s string, sep byte) []string {
+	ps := []string{}
+	if strings.TrimSpace(s) == "" {
+		return ps
+	}
+
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			ps = append(ps, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(ps, strings.TrimSpace(s[start:]))
+}
+
+// sfString returns the string value of the structured field s, unquoting it if
+// it is a quoted string.
+func sfString(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+
+	b := make([]byte, 0, len(s)-2)
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '\\' && i+1 < len(s)-1 {
+			i++
+		}
+		b = append(b, s[i])
+	}
+
+	return string(b)
+}
+
+// SignatureInput returns the signature specifications parsed from the
+// "Signature-Input" header of the r (see RFC 9421) by their labels, so that
+// they can be verified against the "Signature" header.
+func (r *Request) SignatureInput() (map[string]SignatureParams, error) {
+	errMalformed := errors.New("air: malformed signature input")
+
+	sps := map[string]SignatureParams{}
+	for _, m := range sfSplit(r.Headers["Signature-Input"], ',') {
+		i := strings.Index(m, "=")
+		if i <= 0 || !strings.HasPrefix(m[i+1:], "(") {
+			return nil, errMalformed
+		}
+
+		label, m := strings.TrimSpace(m[:i]), m[i+2:]
+
+		j := strings.Index(m, ")")
+		if j < 0 || strings.Count(m[:j], `"`)%2 != 0 {
+			return nil, errMalformed
+		}
+
+		sp := SignatureParams{
+			Components: []string{},
+		}
+
+		for _, c := range sfSplit(m[:j], ' ') {
+			if c == "" {
+				continue
+			} else if c[0] != '"' {
+				return nil, errMalformed
+			}
+
+			ps := sfSplit(c, ';')
+			ps[0] = sfString(ps[0])
+			sp.Components = append(
+				sp.Components,
+				strings.Join(ps, ";"),
+			)
+		}
+
+		ps := strings.TrimSpace(m[j+1:])
+		if ps != "" && ps[0] != ';' {
+			return nil, errMalformed
+		}
+
+		for _, p := range sfSplit(ps, ';') {
+			if p == "" {
+				continue
+			}
+
+			k, v := p, ""
+			if i := strings.Index(p, "="); i >= 0 {
+				k, v = p[:i], p[i+1:]
+			}
+
+			switch k {
+			case "created", "expires":
+				n, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					return nil, errMalformed
+				}
+
+				if k == "created" {
+					sp.Created = time.Unix(n, 0)
+				} else {
+					sp.Expires = time.Unix(n, 0)
+				}
+			case "keyid":
+				sp.KeyID = sfString(v)
+			case "alg":
+				sp.Alg = sfString(v)
+			case "nonce":
+				sp.Nonce = sfString(v)
+			case "tag":
+				sp.Tag = sfString(v)
+			}
+		}
+
+		sps[label] = sp
+	}
+
+	return sps, nil
+}
+
+// SignatureParams is a signature specification of a request.
+type SignatureParams struct {
+	// Components is the covered components, each of which is the component
+	// name followed by its params (e.g. `@query-param;name="foo"`).
+	Components []string
+
+	KeyID   string
+	Alg     string
+	Created time.Time
+	Expires time.Time
+	Nonce   string
+	Tag     string
+}
+
+// Forwarded returns the elements parsed from the "Forwarded" header of the r
+// (see RFC 7239), in the order of the proxies the r passed through. The quoted
+// values are unquoted, so an IPv6 "for" is like the "[2001:db8::1]:4711".
+func (r *Request) Forwarded() []ForwardedElement {
+	hs := []string{r.Headers["Forwarded"]}
+	if r.httpRequest != nil {
+		hs = r.httpRequest.Header["Forwarded"]
+	}
+
+	fes := []ForwardedElement{}
+	for _, h := range hs {
+		for _, e := range sfSplit(h, ',') {
+			if e == "" {
+				continue
+			}
+
+			fe := ForwardedElement{}
+			for _, p := range sfSplit(e, ';') {
+				i := strings.Index(p, "=")
+				if i < 0 {
+					continue
+				}
+
+				k := strings.TrimSpace(p[:i])
+				v := sfString(p[i+1:])
+				switch strings.ToLower(k) {
+				case "for":
+					fe.For = v
+				case "by":
+					fe.By = v
+				case "host":
+					fe.Host = v
+				case "proto":
+					fe.Proto = strings.ToLower(v)
+				}
+			}
+
+			fes = append(fes, fe)
+		}
+	}
+
+	return fes
+}
+
+// ForwardedElement is an element of the "Forwarded" header.
+type ForwardedElement struct {
+	For   string
+	By    string
+	Host  string
+	Proto string
+}
+
+// GeoIP returns the geolocation of the host of the remote address of the r
+// resolved by the resolver. The resolver is injected, so that no database is
+// bundled.
+func (r *Request) GeoIP(
+	resolver func(ip string) (GeoInfo, error),
+) (GeoInfo, error) {
+	ip := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(ip); err == nil {
+		ip = h
+	}
+
+	return resolver(ip)
+}
+
+// GeoInfo is the geolocation of an IP address.
+type GeoInfo struct {
+	Country string
+	Region  string
+	City    string
+}
@@ -1,7 +1,21 @@
 package air
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -21,3 +35,1117 @@ func TestRequest(t *testing.T) {
 	assert.NoError(t, r.Bind(&s))
 	assert.Equal(t, "Foobar", s.Foobar)
 }
+
+func TestRequestRawHeaders(t *testing.T) {
+	r := &Request{
+		Headers: map[string]string{
+			"X-Foo":        "bar",
+			"Content-Type": "application/json",
+			"Accept":       "*/*",
+		},
+	}
+
+	assert.Equal(
+		t,
+		"Accept: */*\r\n"+
+			"Content-Type: application/json\r\n"+
+			"X-Foo: bar\r\n"+
+			"\r\n",
+		string(r.RawHeaders()),
+	)
+
+	r.Headers = map[string]string{}
+	assert.Equal(t, "\r\n", string(r.RawHeaders()))
+
+	var rh []byte
+
+	GET("/raw_headers", func(req *Request, res *Response) error {
+		rh = req.RawHeaders()
+		return nil
+	})
+
+	req := httptest.NewRequest("GET", "/raw_headers", nil)
+	req.Header.Add("Via", "1.1 foo")
+	req.Header.Add("Via", "1.1 bar")
+	req.Header.Add("Cookie", "foo=bar")
+	req.Header.Add("Cookie", "bar=foo")
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(
+		t,
+		"Cookie: foo=bar\r\n"+
+			"Cookie: bar=foo\r\n"+
+			"Via: 1.1 foo\r\n"+
+			"Via: 1.1 bar\r\n"+
+			"\r\n",
+		string(rh),
+	)
+}
+
+func TestRequestIsSafeAndIsIdempotent(t *testing.T) {
+	for _, c := range []struct {
+		method     string
+		safe       bool
+		idempotent bool
+	}{
+		{"GET", true, true},
+		{"HEAD", true, true},
+		{"OPTIONS", true, true},
+		{"TRACE", true, true},
+		{"PUT", false, true},
+		{"DELETE", false, true},
+		{"POST", false, false},
+		{"PATCH", false, false},
+		{"CONNECT", false, false},
+	} {
+		r := &Request{
+			Method: c.method,
+		}
+		assert.Equal(t, c.safe, r.IsSafe(), c.method)
+		assert.Equal(t, c.idempotent, r.IsIdempotent(), c.method)
+	}
+}
+
+func TestRequestQueryParamArray(t *testing.T) {
+	r := &Request{
+		URL: &URL{
+			Query: "id=1&id=2&foo=bar",
+		},
+	}
+	assert.Equal(t, []string{"1", "2"}, r.QueryParamArray("id"))
+	assert.Equal(t, []string{"bar"}, r.QueryParamArray("foo"))
+	assert.Empty(t, r.QueryParamArray("bar"))
+
+	r.URL.Query = "id%5B%5D=1&id[]=2"
+	assert.Equal(t, []string{"1", "2"}, r.QueryParamArray("id"))
+
+	r.URL.Query = "id=1,2"
+	assert.Equal(t, []string{"1,2"}, r.QueryParamArray("id"))
+
+	QueryParamArrayCSVEnabled = true
+
+	assert.Equal(t, []string{"1", "2"}, r.QueryParamArray("id"))
+
+	r.URL.Query = "id=1,2&id=3"
+	assert.Equal(t, []string{"1,2", "3"}, r.QueryParamArray("id"))
+
+	QueryParamArrayCSVEnabled = false
+}
+
+func TestRequestRoutePattern(t *testing.T) {
+	GET("/route_pattern/users/:id", func(*Request, *Response) error {
+		return nil
+	})
+	GET("/route_pattern/users/:id/posts", func(*Request, *Response) error {
+		return nil
+	})
+	GET("/route_pattern/static/", func(*Request, *Response) error {
+		return nil
+	})
+	GET("/route_pattern/files/*", func(*Request, *Response) error {
+		return nil
+	})
+
+	for _, c := range []struct {
+		path    string
+		pattern string
+	}{
+		{"/route_pattern/users/1", "/route_pattern/users/:id"},
+		{"/route_pattern/users/1/posts", "/route_pattern/users/:id/posts"},
+		{"/route_pattern/static", "/route_pattern/static"},
+		{"/route_pattern/files/foo/bar", "/route_pattern/files/*"},
+		{"/route_pattern/none", ""},
+	} {
+		r := &Request{
+			Method: "GET",
+			URL: &URL{
+				Path: c.path,
+			},
+			Params: map[string]string{},
+		}
+		theRouter.route(r)
+		assert.Equal(t, c.pattern, r.RoutePattern(), c.path)
+	}
+}
+
+func TestRequestContentDisposition(t *testing.T) {
+	r := &Request{
+		Headers: map[string]string{
+			"Content-Disposition": `attachment; filename="foo.txt"`,
+		},
+	}
+	dt, fn, ps := r.ContentDisposition()
+	assert.Equal(t, "attachment", dt)
+	assert.Equal(t, "foo.txt", fn)
+	assert.Equal(t, map[string]string{"filename": "foo.txt"}, ps)
+
+	r.Headers["Content-Disposition"] = `attachment; ` +
+		`filename*=UTF-8''%E4%BD%A0%E5%A5%BD%20bar.txt`
+	dt, fn, _ = r.ContentDisposition()
+	assert.Equal(t, "attachment", dt)
+	assert.Equal(t, "你好 bar.txt", fn)
+
+	delete(r.Headers, "Content-Disposition")
+	dt, fn, ps = r.ContentDisposition()
+	assert.Empty(t, dt)
+	assert.Empty(t, fn)
+	assert.Nil(t, ps)
+}
+
+func TestRequestPathAndRawPath(t *testing.T) {
+	r := &Request{
+		Method: "GET",
+		URL: &URL{
+			Path:  "/path/foo%2Fbar/foo%20bar",
+			Query: "foo=bar",
+		},
+		Params: map[string]string{},
+	}
+	assert.Equal(t, "/path/foo/bar/foo bar", r.Path())
+	assert.Equal(t, "/path/foo%2Fbar/foo%20bar", r.RawPath())
+
+	GET("/path/:foo/:bar", func(*Request, *Response) error {
+		return nil
+	})
+	theRouter.route(r)
+	assert.Equal(t, "foo/bar", r.Params["foo"])
+	assert.Equal(t, "foo bar", r.Params["bar"])
+
+	r.URL.Path = "/path/%zz"
+	assert.Equal(t, "/path/%zz", r.Path())
+	assert.Equal(t, "/path/%zz", r.RawPath())
+
+	r.URL = nil
+	assert.Empty(t, r.Path())
+	assert.Empty(t, r.RawPath())
+}
+
+type connRequestNumConn struct {
+	net.Conn
+}
+
+func (connRequestNumConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{
+		IP:   net.IPv4(192, 0, 2, 1),
+		Port: 2333,
+	}
+}
+
+func TestRequestConnIDAndConnRequestNum(t *testing.T) {
+	var reqs []*Request
+	GET("/conn_request_num", func(req *Request, res *Response) error {
+		reqs = append(reqs, req)
+		return nil
+	})
+
+	c := connRequestNumConn{}
+	theServer.connState(c, http.StateNew)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/conn_request_num", nil)
+		req.RemoteAddr = c.RemoteAddr().String()
+		theServer.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req := httptest.NewRequest("GET", "/conn_request_num", nil)
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+
+	theServer.connState(c, http.StateClosed)
+
+	req = httptest.NewRequest("GET", "/conn_request_num", nil)
+	req.RemoteAddr = c.RemoteAddr().String()
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Len(t, reqs, 4)
+	assert.NotZero(t, reqs[0].ConnID())
+	assert.Equal(t, reqs[0].ConnID(), reqs[1].ConnID())
+	assert.Equal(t, uint64(1), reqs[0].ConnRequestNum())
+	assert.Equal(t, uint64(2), reqs[1].ConnRequestNum())
+	assert.Zero(t, reqs[2].ConnID())
+	assert.Zero(t, reqs[2].ConnRequestNum())
+	assert.Zero(t, reqs[3].ConnID())
+	assert.Zero(t, reqs[3].ConnRequestNum())
+}
+
+func TestRequestGetStringAndGetInt(t *testing.T) {
+	r := &Request{
+		Values: map[string]interface{}{
+			"foo": "bar",
+			"bar": 2333,
+		},
+	}
+
+	assert.Equal(t, "bar", r.GetString("foo"))
+	assert.Empty(t, r.GetString("bar"))
+	assert.Empty(t, r.GetString("foobar"))
+
+	i, ok := r.GetInt("bar")
+	assert.True(t, ok)
+	assert.Equal(t, 2333, i)
+
+	i, ok = r.GetInt("foo")
+	assert.False(t, ok)
+	assert.Zero(t, i)
+
+	i, ok = r.GetInt("foobar")
+	assert.False(t, ok)
+	assert.Zero(t, i)
+}
+
+func TestRequestOpenFormFile(t *testing.T) {
+	var (
+		content []byte
+		fh      *multipart.FileHeader
+		err     error
+	)
+
+	POST("/open_form_file", func(req *Request, res *Response) error {
+		var f multipart.File
+		if f, fh, err = req.OpenFormFile("file"); err != nil {
+			return nil
+		}
+		defer f.Close()
+		content, err = ioutil.ReadAll(f)
+		return nil
+	})
+
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	fw, _ := mw.CreateFormFile("file", "foobar.txt")
+	fw.Write([]byte("foobar"))
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/open_form_file", buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "foobar.txt", fh.Filename)
+	assert.Equal(t, "foobar", string(content))
+
+	buf.Reset()
+	mw = multipart.NewWriter(buf)
+	mw.WriteField("foo", "bar")
+	mw.Close()
+
+	req = httptest.NewRequest("POST", "/open_form_file", buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, http.ErrMissingFile, err)
+
+	r := &Request{}
+	_, _, err = r.OpenFormFile("file")
+	assert.Equal(t, http.ErrMissingFile, err)
+}
+
+func TestRequestParseForm(t *testing.T) {
+	var err error
+
+	POST("/parse_form", func(req *Request, res *Response) error {
+		err = req.ParseForm()
+		return nil
+	})
+
+	req := httptest.NewRequest(
+		"POST",
+		"/parse_form?foo=bar",
+		strings.NewReader("bar=foo"),
+	)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+	assert.NoError(t, err)
+
+	req = httptest.NewRequest(
+		"POST",
+		"/parse_form",
+		strings.NewReader("--foobar\r\nfoobar"),
+	)
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=foobar")
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Error(t, err)
+
+	r := &Request{}
+	assert.NoError(t, r.ParseForm())
+}
+
+func TestRequestFormValueBytes(t *testing.T) {
+	var foo, bar, foobar []byte
+	POST("/form_value_bytes", func(req *Request, res *Response) error {
+		foo = req.FormValueBytes("foo")
+		bar = req.FormValueBytes("bar")
+		foobar = req.FormValueBytes("foobar")
+		return nil
+	})
+
+	req := httptest.NewRequest(
+		"POST",
+		"/form_value_bytes?bar=%FF%00",
+		strings.NewReader("foo=a%00b%00"),
+	)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, []byte{'a', 0, 'b', 0}, foo)
+	assert.Equal(t, []byte{0xff, 0}, bar)
+	assert.Nil(t, foobar)
+
+	r := &Request{}
+	assert.Nil(t, r.FormValueBytes("foo"))
+}
+
+func TestRequestDump(t *testing.T) {
+	r := &Request{
+		Method: "POST",
+		URL: &URL{
+			Host:  "example.com",
+			Path:  "/foo",
+			Query: "bar=foobar",
+		},
+		Proto: "HTTP/1.1",
+		Headers: map[string]string{
+			"Content-Type": "text/plain",
+		},
+		Body: strings.NewReader("foobar"),
+	}
+
+	b, err := r.Dump(false)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"POST /foo?bar=foobar HTTP/1.1\r\n"+
+			"Host: example.com\r\n"+
+			"Content-Type: text/plain\r\n"+
+			"\r\n",
+		string(b),
+	)
+
+	b, err = r.Dump(true)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"POST /foo?bar=foobar HTTP/1.1\r\n"+
+			"Host: example.com\r\n"+
+			"Content-Type: text/plain\r\n"+
+			"\r\n"+
+			"foobar",
+		string(b),
+	)
+
+	b, err = ioutil.ReadAll(r.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "foobar", string(b))
+}
+
+type slowReader struct {
+	delay  time.Duration
+	reader io.Reader
+}
+
+func (sr *slowReader) Read(b []byte) (int, error) {
+	time.Sleep(sr.delay)
+	if len(b) > 1 {
+		b = b[:1]
+	}
+	return sr.reader.Read(b)
+}
+
+func TestRequestBodyReceivedAndAwaitBody(t *testing.T) {
+	var (
+		before bool
+		after  bool
+		err    error
+		body   []byte
+	)
+
+	PUT("/await_body", func(req *Request, res *Response) error {
+		before = req.BodyReceived()
+		err = req.AwaitBody(50*time.Millisecond, 10)
+		after = req.BodyReceived()
+		body, _ = ioutil.ReadAll(req.Body)
+		return nil
+	})
+
+	req := httptest.NewRequest(
+		"PUT",
+		"/await_body",
+		strings.NewReader("foobar"),
+	)
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+	assert.False(t, before)
+	assert.NoError(t, err)
+	assert.True(t, after)
+	assert.Equal(t, "foobar", string(body))
+
+	req = httptest.NewRequest("PUT", "/await_body", &slowReader{
+		delay:  20 * time.Millisecond,
+		reader: strings.NewReader("foobar"),
+	})
+	req.ContentLength = 6
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+	assert.False(t, before)
+	assert.Equal(t, &Error{408, "Request Timeout"}, err)
+	assert.False(t, after)
+	assert.Equal(t, "foobar", string(body))
+
+	req = httptest.NewRequest("PUT", "/await_body", &slowReader{
+		reader: strings.NewReader("foobarfoobar"),
+	})
+	req.ContentLength = 12
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, &Error{413, "Request Entity Too Large"}, err)
+	assert.False(t, after)
+	assert.Equal(t, "foobarfoobar", string(body))
+
+	req = httptest.NewRequest("PUT", "/await_body", nil)
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, before)
+	assert.NoError(t, err)
+	assert.True(t, after)
+	assert.Empty(t, body)
+
+	r := &Request{}
+	assert.True(t, r.BodyReceived())
+	assert.NoError(t, r.AwaitBody(time.Millisecond, 0))
+}
+
+func TestRequestSignedCookie(t *testing.T) {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("foobar"))
+	sig := hex.EncodeToString(mac.Sum(nil))
+
+	r := &Request{
+		Cookies: []*Cookie{
+			{
+				Name:  "foo",
+				Value: "foobar|" + sig,
+			},
+			{
+				Name:  "bar",
+				Value: "foobaz|" + sig,
+			},
+			{
+				Name:  "foobar",
+				Value: "foobar",
+			},
+		},
+	}
+
+	v, err := r.SignedCookie("foo", "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "foobar", v)
+
+	v, err = r.SignedCookie("foo", "terces")
+	assert.Error(t, err)
+	assert.Empty(t, v)
+
+	v, err = r.SignedCookie("bar", "secret")
+	assert.Error(t, err)
+	assert.Empty(t, v)
+
+	v, err = r.SignedCookie("foobar", "secret")
+	assert.Error(t, err)
+	assert.Empty(t, v)
+
+	v, err = r.SignedCookie("baz", "secret")
+	assert.Error(t, err)
+	assert.Empty(t, v)
+}
+
+func TestRequestFormValueDefault(t *testing.T) {
+	var foo, bar, foobar string
+	POST("/form_value_default", func(req *Request, res *Response) error {
+		foo = req.FormValueDefault("foo", "default")
+		bar = req.FormValueDefault("bar", "default")
+		foobar = req.FormValueDefault("foobar", "default")
+		return nil
+	})
+
+	req := httptest.NewRequest(
+		"POST",
+		"/form_value_default",
+		strings.NewReader("foo=bar&bar="),
+	)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "bar", foo)
+	assert.Equal(t, "default", bar)
+	assert.Equal(t, "default", foobar)
+}
+
+func TestRequestPrefer(t *testing.T) {
+	r := &Request{
+		Headers: map[string]string{
+			"Prefer": `return=minimal; foo="bar", respond-async, ` +
+				`wait="10"`,
+		},
+	}
+
+	v, ok := r.Prefer("return")
+	assert.True(t, ok)
+	assert.Equal(t, "minimal", v)
+
+	v, ok = r.Prefer("Respond-Async")
+	assert.True(t, ok)
+	assert.Empty(t, v)
+
+	v, ok = r.Prefer("wait")
+	assert.True(t, ok)
+	assert.Equal(t, "10", v)
+
+	v, ok = r.Prefer("handling")
+	assert.False(t, ok)
+	assert.Empty(t, v)
+
+	delete(r.Headers, "Prefer")
+	v, ok = r.Prefer("return")
+	assert.False(t, ok)
+	assert.Empty(t, v)
+}
+
+func TestRequestStreamBody(t *testing.T) {
+	r := &Request{
+		Body: strings.NewReader("foobarfoobarfoo"),
+	}
+
+	chunks := []string{}
+	assert.NoError(t, r.StreamBody(6, func(b []byte) error {
+		chunks = append(chunks, string(b))
+		return nil
+	}))
+	assert.Equal(t, []string{"foobar", "foobar", "foo"}, chunks)
+
+	r.Body = strings.NewReader("foobarfoobarfoo")
+	chunks = chunks[:0]
+	err := errors.New("foobar")
+	assert.Equal(t, err, r.StreamBody(6, func(b []byte) error {
+		chunks = append(chunks, string(b))
+		if len(chunks) == 2 {
+			return err
+		}
+		return nil
+	}))
+	assert.Equal(t, []string{"foobar", "foobar"}, chunks)
+
+	assert.Error(t, r.StreamBody(0, func([]byte) error {
+		return nil
+	}))
+
+	r.Body = nil
+	assert.NoError(t, r.StreamBody(6, func([]byte) error {
+		return errors.New("foobar")
+	}))
+}
+
+func TestRequestBodyReadCloser(t *testing.T) {
+	r := &Request{
+		Body: strings.NewReader("foobar"),
+	}
+
+	rc := r.BodyReadCloser()
+	b, err := ioutil.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, "foobar", string(b))
+	assert.NoError(t, rc.Close())
+	assert.NotPanics(t, func() {
+		assert.NoError(t, rc.Close())
+	})
+
+	_, err = rc.Read(make([]byte, 1))
+	assert.Error(t, err)
+
+	r.Body = nil
+	rc = r.BodyReadCloser()
+	b, err = ioutil.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.Empty(t, b)
+	assert.NoError(t, rc.Close())
+}
+
+func TestRequestUserAgentInfo(t *testing.T) {
+	r := &Request{
+		Headers: map[string]string{
+			"User-Agent": "Mozilla/5.0 " +
+				"(Windows NT 10.0; Win64; x64) " +
+				"AppleWebKit/537.36 (KHTML, like Gecko) " +
+				"Chrome/120.0.0.0 Safari/537.36",
+		},
+	}
+
+	uai := r.UserAgentInfo()
+	assert.Equal(t, "Chrome", uai.Browser)
+	assert.Equal(t, "120.0.0.0", uai.BrowserVersion)
+	assert.Equal(t, "Windows", uai.OS)
+	assert.Equal(t, "Desktop", uai.Device)
+	assert.False(t, uai.IsBot)
+
+	r.Headers["User-Agent"] = "Mozilla/5.0 (iPhone; CPU iPhone OS 16_0 " +
+		"like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) " +
+		"Version/16.0 Mobile/15E148 Safari/604.1"
+
+	uai = r.UserAgentInfo()
+	assert.Equal(t, "Safari", uai.Browser)
+	assert.Equal(t, "16.0", uai.BrowserVersion)
+	assert.Equal(t, "iOS", uai.OS)
+	assert.Equal(t, "Mobile", uai.Device)
+	assert.False(t, uai.IsBot)
+
+	r.Headers["User-Agent"] = "Mozilla/5.0 (compatible; Googlebot/2.1; " +
+		"+http://www.google.com/bot.html)"
+
+	uai = r.UserAgentInfo()
+	assert.Equal(t, "Googlebot", uai.Browser)
+	assert.Equal(t, "2.1", uai.BrowserVersion)
+	assert.Equal(t, "Bot", uai.Device)
+	assert.True(t, uai.IsBot)
+
+	delete(r.Headers, "User-Agent")
+	assert.Equal(t, UserAgentInfo{}, r.UserAgentInfo())
+}
+
+func TestRequestDecodeXML(t *testing.T) {
+	type foo struct {
+		Bar string `xml:"bar"`
+	}
+
+	r := &Request{
+		Headers: map[string]string{
+			"Content-Type": "application/xml; charset=utf-8",
+		},
+		Body: strings.NewReader("<foo><bar>foobar</bar></foo>"),
+	}
+
+	f := foo{}
+	assert.NoError(t, r.DecodeXML(&f))
+	assert.Equal(t, "foobar", f.Bar)
+
+	r.Body = strings.NewReader("<foo><bar>foobar</foo>")
+	err := r.DecodeXML(&foo{})
+	assert.Error(t, err)
+	assert.Equal(t, 400, err.(*Error).Code)
+
+	r.Headers["Content-Type"] = "application/json"
+	r.Body = strings.NewReader("<foo><bar>foobar</bar></foo>")
+	err = r.DecodeXML(&foo{})
+	assert.Error(t, err)
+	assert.Equal(t, 415, err.(*Error).Code)
+
+	XMLContentTypeLenient = true
+
+	f = foo{}
+	assert.NoError(t, r.DecodeXML(&f))
+	assert.Equal(t, "foobar", f.Bar)
+
+	XMLContentTypeLenient = false
+}
+
+func TestRequestDone(t *testing.T) {
+	started := make(chan struct{})
+	done := make(chan struct{})
+
+	GET("/done", func(req *Request, res *Response) error {
+		close(started)
+		select {
+		case <-req.Done():
+			close(done)
+		case <-time.After(5 * time.Second):
+		}
+		return nil
+	})
+
+	s := httptest.NewServer(theServer)
+	defer s.Close()
+
+	c, err := net.Dial("tcp", s.Listener.Addr().String())
+	assert.NoError(t, err)
+
+	_, err = c.Write([]byte("GET /done HTTP/1.1\r\nHost: foobar\r\n\r\n"))
+	assert.NoError(t, err)
+
+	<-started
+	c.Close()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("the done channel was not closed")
+	}
+
+	r := &Request{}
+	assert.Nil(t, r.Done())
+}
+
+func TestRequestClientHints(t *testing.T) {
+	r := &Request{
+		Headers: map[string]string{
+			"Sec-Ch-Ua": `"Chromium";v="120", ` +
+				`"Google Chrome";v="120", "Not?A_Brand";v="99"`,
+			"Sec-Ch-Ua-Platform":         `"macOS"`,
+			"Sec-Ch-Ua-Platform-Version": `"14.1.0"`,
+			"Sec-Ch-Ua-Mobile":           "?0",
+			"Sec-Ch-Ua-Model":            `""`,
+			"Sec-Ch-Ua-Arch":             `"arm"`,
+			"Sec-Ch-Ua-Bitness":          `"64"`,
+			"Sec-Ch-Ua-Full-Version":     `"120.0.6099.109"`,
+		},
+	}
+
+	ch := r.ClientHints()
+	assert.Equal(t, []ClientHintsBrand{
+		{"Chromium", "120"},
+		{"Google Chrome", "120"},
+		{"Not?A_Brand", "99"},
+	}, ch.Brands)
+	assert.Equal(t, "macOS", ch.Platform)
+	assert.Equal(t, "14.1.0", ch.PlatformVersion)
+	assert.False(t, ch.Mobile)
+	assert.Equal(t, "", ch.Model)
+	assert.Equal(t, "arm", ch.Arch)
+	assert.Equal(t, "64", ch.Bitness)
+	assert.Equal(t, "120.0.6099.109", ch.FullVersion)
+
+	r.Headers["Sec-Ch-Ua-Mobile"] = "?1"
+	assert.True(t, r.ClientHints().Mobile)
+
+	r.Headers = map[string]string{}
+	assert.Equal(t, ClientHints{}, r.ClientHints())
+}
+
+func TestRequestSignatureInput(t *testing.T) {
+	r := &Request{
+		Headers: map[string]string{
+			"Signature-Input": `sig1=("@method" "@target-uri" ` +
+				`"@query-param";name="foo" "content-digest")` +
+				`;created=1618884473;expires=1618884773` +
+				`;keyid="test-key";alg="ed25519", sig2=()` +
+				`;nonce="foobar";tag="app"`,
+		},
+	}
+
+	sps, err := r.SignatureInput()
+	assert.NoError(t, err)
+	assert.Len(t, sps, 2)
+	assert.Equal(t, SignatureParams{
+		Components: []string{
+			"@method",
+			"@target-uri",
+			`@query-param;name="foo"`,
+			"content-digest",
+		},
+		KeyID:   "test-key",
+		Alg:     "ed25519",
+		Created: time.Unix(1618884473, 0),
+		Expires: time.Unix(1618884773, 0),
+	}, sps["sig1"])
+	assert.Equal(t, SignatureParams{
+		Components: []string{},
+		Nonce:      "foobar",
+		Tag:        "app",
+	}, sps["sig2"])
+
+	r.Headers["Signature-Input"] = `sig1=("@method";created=foo`
+	_, err = r.SignatureInput()
+	assert.Error(t, err)
+
+	r.Headers["Signature-Input"] = `sig1=("@method");created=foo`
+	_, err = r.SignatureInput()
+	assert.Error(t, err)
+
+	r.Headers["Signature-Input"] = `sig1=("@method")foo`
+	_, err = r.SignatureInput()
+	assert.Error(t, err)
+
+	r.Headers["Signature-Input"] = `sig1="@method"`
+	_, err = r.SignatureInput()
+	assert.Error(t, err)
+
+	r.Headers["Signature-Input"] = `sig1=("@method")`
+	sps, err = r.SignatureInput()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"@method"}, sps["sig1"].Components)
+
+	delete(r.Headers, "Signature-Input")
+	sps, err = r.SignatureInput()
+	assert.NoError(t, err)
+	assert.Empty(t, sps)
+}
+
+func TestRequestStripHopByHop(t *testing.T) {
+	var headers map[string]string
+
+	GET("/strip_hop_by_hop", func(req *Request, res *Response) error {
+		req.StripHopByHop()
+		headers = req.Headers
+		return nil
+	})
+
+	req := httptest.NewRequest("GET", "/strip_hop_by_hop", nil)
+	req.Header.Set("Connection", "keep-alive, x-foo")
+	req.Header.Add("Connection", "X-Bar")
+	req.Header.Set("Keep-Alive", "timeout=5")
+	req.Header.Set("Proxy-Authenticate", "Basic")
+	req.Header.Set("TE", "trailers")
+	req.Header.Set("Trailers", "X-Checksum")
+	req.Header.Set("Transfer-Encoding", "chunked")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("X-Foo", "foo")
+	req.Header.Set("X-Bar", "bar")
+	req.Header.Set("X-Baz", "baz")
+	req.Header.Set("Accept", "*/*")
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, map[string]string{
+		"X-Baz":  "baz",
+		"Accept": "*/*",
+	}, headers)
+	assert.Equal(t, http.Header{
+		"X-Baz":  []string{"baz"},
+		"Accept": []string{"*/*"},
+	}, req.Header)
+
+	r := &Request{
+		Headers: map[string]string{
+			"Connection": "X-Foo",
+			"Upgrade":    "h2c",
+			"X-Foo":      "foo",
+			"X-Bar":      "bar",
+		},
+	}
+
+	r.StripHopByHop()
+	assert.Equal(t, map[string]string{
+		"X-Bar": "bar",
+	}, r.Headers)
+}
+
+func TestRequestExpectsContinue(t *testing.T) {
+	r := &Request{
+		Headers: map[string]string{
+			"Expect": "100-Continue",
+		},
+	}
+	assert.True(t, r.ExpectsContinue())
+
+	r.Headers["Expect"] = "foobar"
+	assert.False(t, r.ExpectsContinue())
+
+	delete(r.Headers, "Expect")
+	assert.False(t, r.ExpectsContinue())
+}
+
+func TestRequestCookieJSON(t *testing.T) {
+	type foo struct {
+		Bar string `json:"bar"`
+	}
+
+	r := &Request{
+		Cookies: []*Cookie{
+			{
+				Name:  "url",
+				Value: "%7B%22bar%22%3A%22foobar%22%7D",
+			},
+			{
+				Name:  "base64",
+				Value: "eyJiYXIiOiJmb29iYXIifQ",
+			},
+			{
+				Name:  "malformed",
+				Value: "%7B%22bar%22",
+			},
+		},
+	}
+
+	f := foo{}
+	assert.NoError(t, r.CookieJSON("url", &f))
+	assert.Equal(t, "foobar", f.Bar)
+
+	assert.Error(t, r.CookieJSON("absent", &foo{}))
+	assert.Error(t, r.CookieJSON("malformed", &foo{}))
+	assert.Error(t, r.CookieJSON("base64", &foo{}))
+
+	CookieJSONEncoding = "base64"
+
+	f = foo{}
+	assert.NoError(t, r.CookieJSON("base64", &f))
+	assert.Equal(t, "foobar", f.Bar)
+
+	assert.Error(t, r.CookieJSON("url", &foo{}))
+
+	CookieJSONEncoding = "url"
+}
+
+func TestRequestForwarded(t *testing.T) {
+	r := &Request{
+		Headers: map[string]string{
+			"Forwarded": "for=192.0.2.60;proto=HTTP" +
+				";by=203.0.113.43;host=example.com",
+		},
+	}
+
+	assert.Equal(t, []ForwardedElement{
+		{
+			For:   "192.0.2.60",
+			By:    "203.0.113.43",
+			Host:  "example.com",
+			Proto: "http",
+		},
+	}, r.Forwarded())
+
+	var fes []ForwardedElement
+
+	GET("/forwarded", func(req *Request, res *Response) error {
+		fes = req.Forwarded()
+		return nil
+	})
+
+	req := httptest.NewRequest("GET", "/forwarded", nil)
+	req.Header.Add("Forwarded", `For="[2001:db8:cafe::17]:4711"`)
+	req.Header.Add("Forwarded", "for=192.0.2.43, for=198.51.100.17;"+
+		`proto=https;host="example.com:8080"`)
+	theServer.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, []ForwardedElement{
+		{
+			For: "[2001:db8:cafe::17]:4711",
+		},
+		{
+			For: "192.0.2.43",
+		},
+		{
+			For:   "198.51.100.17",
+			Host:  "example.com:8080",
+			Proto: "https",
+		},
+	}, fes)
+
+	r.Headers = map[string]string{}
+	assert.Empty(t, r.Forwarded())
+}
+
+func TestRequestGeoIP(t *testing.T) {
+	r := &Request{
+		Headers: map[string]string{
+			"X-Forwarded-For": "192.0.2.2",
+		},
+		RemoteAddr: "192.0.2.1:1234",
+	}
+
+	gi, err := r.GeoIP(func(ip string) (GeoInfo, error) {
+		if ip != "192.0.2.1" {
+			return GeoInfo{}, errors.New("unknown ip")
+		}
+		return GeoInfo{
+			Country: "NZ",
+			Region:  "Auckland",
+			City:    "Auckland",
+		}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, GeoInfo{
+		Country: "NZ",
+		Region:  "Auckland",
+		City:    "Auckland",
+	}, gi)
+
+	_, err = r.GeoIP(func(ip string) (GeoInfo, error) {
+		return GeoInfo{}, errors.New("foobar")
+	})
+	assert.EqualError(t, err, "foobar")
+}
+
+func TestRequestMultipartWithProgress(t *testing.T) {
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	fw, _ := mw.CreateFormFile("file", "foobar.txt")
+	fw.Write(bytes.Repeat([]byte("foobar"), 1000))
+	mw.Close()
+
+	total := int64(buf.Len())
+
+	r := &Request{
+		Headers: map[string]string{
+			"Content-Type": mw.FormDataContentType(),
+		},
+		ContentLength: total,
+		Body:          buf,
+	}
+
+	reads := []int64{}
+	r.MultipartWithProgress(func(read, n int64) {
+		assert.Equal(t, total, n)
+		reads = append(reads, read)
+	})
+
+	mr := multipart.NewReader(r.Body, mw.Boundary())
+	p, err := mr.NextPart()
+	assert.NoError(t, err)
+
+	b, err := ioutil.ReadAll(p)
+	assert.NoError(t, err)
+	assert.Len(t, b, 6000)
+
+	_, err = mr.NextPart()
+	assert.Equal(t, io.EOF, err)
+
+	assert.True(t, len(reads) > 1)
+	for i := 1; i < len(reads); i++ {
+		assert.True(t, reads[i] > reads[i-1])
+	}
+	assert.Equal(t, total, reads[len(reads)-1])
+
+	reads = reads[:0]
+
+	POST(
+		"/multipart_with_progress",
+		func(req *Request, res *Response) error {
+			b, err := ioutil.ReadAll(req.Files["file"])
+			if err != nil {
+				return err
+			}
+			return res.String(strconv.Itoa(len(b)))
+		},
+		func(next Handler) Handler {
+			return func(req *Request, res *Response) error {
+				req.MultipartWithProgress(func(read, n int64) {
+					assert.Equal(t, total, n)
+					reads = append(reads, read)
+				})
+				return next(req, res)
+			}
+		},
+	)
+
+	buf.Reset()
+	mw = multipart.NewWriter(buf)
+	fw, _ = mw.CreateFormFile("file", "foobar.txt")
+	fw.Write(bytes.Repeat([]byte("foobar"), 1000))
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/multipart_with_progress", buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	theServer.ServeHTTP(rec, req)
+	assert.Equal(t, "6000", rec.Body.String())
+	assert.True(t, len(reads) > 1)
+	for i := 1; i < len(reads); i++ {
+		assert.True(t, reads[i] > reads[i-1])
+	}
+	assert.Equal(t, total, reads[len(reads)-1])
+}
+
+func TestRequestDrainBody(t *testing.T) {
+	body := strings.NewReader("foobar")
+	r := &Request{
+		Body: body,
+	}
+	assert.NoError(t, r.DrainBody(1<<20))
+	assert.Zero(t, body.Len())
+
+	body = strings.NewReader("foobar")
+	r.Body = body
+	assert.Error(t, r.DrainBody(3))
+	assert.Equal(t, 2, body.Len())
+
+	body = strings.NewReader("foobar")
+	r.Body = body
+	assert.NoError(t, r.DrainBody(0))
+	assert.Zero(t, body.Len())
+
+	r.Body = nil
+	assert.NoError(t, r.DrainBody(1<<20))
+}
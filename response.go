@@ -71,6 +71,18 @@ func (r *Response) write(b []byte) error {
 	return nil
 }
 
+// HTTPResponseWriter returns the underlying `http.ResponseWriter` of the r.
+func (r *Response) HTTPResponseWriter() http.ResponseWriter {
+	return r.httpResponseWriter
+}
+
+// SetHTTPResponseWriter sets the underlying `http.ResponseWriter` of the r to
+// the rw. It can be used by the gases to intercept what the subsequent handlers
+// respond to the client.
+func (r *Response) SetHTTPResponseWriter(rw http.ResponseWriter) {
+	r.httpResponseWriter = rw
+}
+
 // NoContent responds to the client with no content.
 func (r *Response) NoContent() error {
 	return r.write(nil)
@@ -255,20 +267,35 @@ func (r *Response) File(file string) error {
 	return nil
 }
 
-// Flush flushes buffered data to the client.
+// Flush flushes buffered data to the client. It does nothing if the underlying
+// `http.ResponseWriter` of the r does not support flushing.
 func (r *Response) Flush() {
-	r.httpResponseWriter.(http.Flusher).Flush()
+	if f, ok := r.httpResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
 }
 
-// Hijack took over the connection from the server.
+// Hijack took over the connection from the server. It returns the
+// `http.ErrNotSupported` if the underlying `http.ResponseWriter` of the r does
+// not support hijacking.
 func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return r.httpResponseWriter.(http.Hijacker).Hijack()
+	h, ok := r.httpResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return h.Hijack()
 }
 
 // CloseNotify returns a channel that receives at most a single value when the
-// connection has gone away.
+// connection has gone away. The returned channel never receives if the
+// underlying `http.ResponseWriter` of the r does not support close
+// notification.
 func (r *Response) CloseNotify() <-chan bool {
-	return r.httpResponseWriter.(http.CloseNotifier).CloseNotify()
+	cn, ok := r.httpResponseWriter.(http.CloseNotifier)
+	if !ok {
+		return nil
+	}
+	return cn.CloseNotify()
 }
 
 // Push initiates an HTTP/2 server push. This constructs a synthetic request
@@ -279,7 +306,9 @@ func (r *Response) CloseNotify() <-chan bool {
 // request. If the target is a path, it will inherit the scheme and host of the
 // parent request. The headers specifies additional promised request headers.
 // The headers cannot include HTTP/2 pseudo header fields like ":path" and
-// ":scheme", which will be added automatically.
+// ":scheme", which will be added automatically. It returns the
+// `http.ErrNotSupported` if the underlying `http.ResponseWriter` of the r does
+// not support the HTTP/2 server push.
 func (r *Response) Push(target string, headers map[string]string) error {
 	var pos *http.PushOptions
 	for k, v := range headers {
@@ -290,7 +319,11 @@ func (r *Response) Push(target string, headers map[string]string) error {
 		}
 		pos.Header.Set(k, v)
 	}
-	return r.httpResponseWriter.(http.Pusher).Push(target, pos)
+	p, ok := r.httpResponseWriter.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return p.Push(target, pos)
 }
 
 // checkPreconditions evaluates request preconditions and reports whether a
@@ -25,6 +25,8 @@ func (r *router) register(method, path string, h Handler, gases ...Gas) {
 		path = path[:len(path)-1]
 	}
 
+	pattern := path
+
 	if path == "" {
 		panic("air: the path cannot be empty")
 	} else if path[0] != '/' {
@@ -54,8 +56,15 @@ func (r *router) register(method, path string, h Handler, gases ...Gas) {
 		}
 	}
 
+	// The body form data are parsed right before the h, so that the gases
+	// can inspect or wrap the request body before it is read.
+	fh := func(req *Request, res *Response) error {
+		req.ParseForm()
+		return h(req, res)
+	}
+
 	nh := func(req *Request, res *Response) error {
-		h := h
+		h := fh
 		for i := len(gases) - 1; i >= 0; i-- {
 			h = gases[i](h)
 		}
@@ -68,7 +77,7 @@ func (r *router) register(method, path string, h Handler, gases ...Gas) {
 		if path[i] == ':' {
 			j := i + 1
 
-			r.insert(method, path[:i], nil, static, nil)
+			r.insert(method, path[:i], nil, static, nil, "")
 
 			for ; i < l && path[i] != '/'; i++ {
 			}
@@ -86,20 +95,20 @@ func (r *router) register(method, path string, h Handler, gases ...Gas) {
 			path = path[:j] + path[i:]
 
 			if i, l = j, len(path); i == l {
-				r.insert(method, path, nh, param, paramNames)
+				r.insert(method, path, nh, param, paramNames, pattern)
 				return
 			}
 
-			r.insert(method, path[:i], nil, param, paramNames)
+			r.insert(method, path[:i], nil, param, paramNames, "")
 		} else if path[i] == '*' {
-			r.insert(method, path[:i], nil, static, nil)
+			r.insert(method, path[:i], nil, static, nil, "")
 			paramNames = append(paramNames, "*")
-			r.insert(method, path[:i+1], nh, any, paramNames)
+			r.insert(method, path[:i+1], nh, any, paramNames, pattern)
 			return
 		}
 	}
 
-	r.insert(method, path, nh, static, paramNames)
+	r.insert(method, path, nh, static, paramNames, pattern)
 }
 
 // insert inserts a new route into the `tree` of the r.
@@ -109,6 +118,7 @@ func (r *router) insert(
 	h Handler,
 	nk nodeKind,
 	paramNames []string,
+	pattern string,
 ) {
 	if l := len(paramNames); l > r.maxParams {
 		r.maxParams = l
@@ -146,6 +156,7 @@ func (r *router) insert(
 				cn.kind = nk
 				cn.handlers[method] = h
 				cn.paramNames = paramNames
+				cn.pattern = pattern
 			}
 		} else if ll < pl {
 			// Split node
@@ -157,6 +168,7 @@ func (r *router) insert(
 				parent:     cn,
 				children:   cn.children,
 				paramNames: cn.paramNames,
+				pattern:    cn.pattern,
 			}
 
 			// Reset parent node
@@ -166,6 +178,7 @@ func (r *router) insert(
 			cn.children = nil
 			cn.handlers = map[string]Handler{}
 			cn.paramNames = nil
+			cn.pattern = ""
 			cn.children = append(cn.children, nn)
 
 			if ll == sl {
@@ -173,6 +186,7 @@ func (r *router) insert(
 				cn.kind = nk
 				cn.handlers[method] = h
 				cn.paramNames = paramNames
+				cn.pattern = pattern
 			} else {
 				// Create child node
 				nn = &node{
@@ -182,6 +196,7 @@ func (r *router) insert(
 					handlers:   map[string]Handler{},
 					parent:     cn,
 					paramNames: paramNames,
+					pattern:    pattern,
 				}
 				nn.handlers[method] = h
 				cn.children = append(cn.children, nn)
@@ -203,6 +218,7 @@ func (r *router) insert(
 				handlers:   map[string]Handler{},
 				parent:     cn,
 				paramNames: paramNames,
+				pattern:    pattern,
 			}
 			nn.handlers[method] = h
 			cn.children = append(cn.children, nn)
@@ -210,6 +226,7 @@ func (r *router) insert(
 			// Node already exists
 			cn.handlers[method] = h
 			cn.paramNames = paramNames
+			cn.pattern = pattern
 		}
 
 		return
@@ -340,6 +357,8 @@ func (r *router) route(req *Request) Handler {
 		for i := range pvs {
 			req.Params[cn.paramNames[i]] = pvs[i]
 		}
+		req.routePattern = cn.pattern
+		req.routeParamNames = cn.paramNames
 		return handler
 	} else if len(cn.handlers) != 0 {
 		return MethodNotAllowedHandler
@@ -467,6 +486,7 @@ type node struct {
 	parent     *node
 	children   []*node
 	paramNames []string
+	pattern    string
 }
 
 // nodeKind is a kind of the `node`.
@@ -7,17 +7,30 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // server is an HTTP server.
 type server struct {
-	server *http.Server
+	server     *http.Server
+	conns      map[string]*connInfo
+	connsMutex *sync.Mutex
+	lastConnID uint64
 }
 
 // theServer is the singleton of the `server`.
 var theServer = &server{
-	server: &http.Server{},
+	server:     &http.Server{},
+	conns:      map[string]*connInfo{},
+	connsMutex: &sync.Mutex{},
+}
+
+// connInfo is the metadata of a connection accepted by the `server`.
+type connInfo struct {
+	id       uint64
+	requests uint64
 }
 
 // serve starts the s.
@@ -29,6 +42,7 @@ func (s *server) serve() error {
 	s.server.WriteTimeout = WriteTimeout
 	s.server.IdleTimeout = IdleTimeout
 	s.server.MaxHeaderBytes = MaxHeaderBytes
+	s.server.ConnState = s.connState
 
 	if DebugMode {
 		LoggerEnabled = true
@@ -83,10 +97,32 @@ func (s *server) shutdown(timeout time.Duration) error {
 	return s.server.Shutdown(c)
 }
 
+// connState tracks the metadata of the c based on the state.
+func (s *server) connState(c net.Conn, state http.ConnState) {
+	ra := c.RemoteAddr().String()
+	switch state {
+	case http.StateNew:
+		s.connsMutex.Lock()
+		s.conns[ra] = &connInfo{
+			id: atomic.AddUint64(&s.lastConnID, 1),
+		}
+		s.connsMutex.Unlock()
+	case http.StateHijacked, http.StateClosed:
+		s.connsMutex.Lock()
+		delete(s.conns, ra)
+		s.connsMutex.Unlock()
+	}
+}
+
 // ServeHTTP implements the `http.Handler`.
 func (s *server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	// Request
 
+	bc := &bodyCounter{
+		ReadCloser: r.Body,
+	}
+	r.Body = bc
+
 	req := &Request{
 		Method: r.Method,
 		URL: &URL{
@@ -104,12 +140,22 @@ func (s *server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		Files:         map[string]io.Reader{},
 		RemoteAddr:    r.RemoteAddr,
 		Values:        map[string]interface{}{},
+
+		httpRequest: r,
+		bodyCounter: bc,
 	}
 
 	if r.TLS != nil {
 		req.URL.Scheme = "https"
 	}
 
+	s.connsMutex.Lock()
+	if ci, ok := s.conns[r.RemoteAddr]; ok {
+		req.connID = ci.id
+		req.connRequestNum = atomic.AddUint64(&ci.requests, 1)
+	}
+	s.connsMutex.Unlock()
+
 	if r.ProtoMajor < 2 {
 		req.Proto += "." + strconv.Itoa(r.ProtoMinor)
 	}
@@ -150,26 +196,12 @@ func (s *server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if r.Form == nil || r.MultipartForm == nil {
-		r.ParseMultipartForm(32 << 20)
-	}
-
-	for k, v := range r.Form {
+	for k, v := range r.URL.Query() {
 		if len(v) > 0 {
 			req.Params[k] = v[0]
 		}
 	}
 
-	if r.MultipartForm != nil {
-		for k, v := range r.MultipartForm.File {
-			if len(v) > 0 {
-				if f, err := v[0].Open(); err == nil {
-					req.Files[k] = f
-				}
-			}
-		}
-	}
-
 	// Response
 
 	res := &Response{